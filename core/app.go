@@ -15,8 +15,9 @@
 package core
 
 import (
+	"context"
 	"log"
-	"notifications/driven/core"
+	"notifications/core/model"
 	"notifications/driven/mailer"
 
 	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
@@ -34,12 +35,21 @@ func (sl *storageListener) OnFirebaseConfigurationsUpdated() {
 	firebaseConfs, err := sl.app.storage.LoadFirebaseConfigurations()
 	if err != nil {
 		log.Printf("Error getting the firebase configurations when updated - %s", err.Error())
+		return //keep the current configurations
 	}
 
 	err = sl.app.firebase.UpdateFirebaseConfigurations(firebaseConfs)
 	if err != nil {
 		log.Printf("Error setting the firebase configurations when updated - %s", err.Error())
 	}
+
+	// set the updated delivery rate limits
+	sl.app.queueLogic.rateLimiter.setLimits(firebaseConfs)
+}
+
+// OnQueueDataInserted notifies that items have been added to the queue, also by the other instances
+func (sl *storageListener) OnQueueDataInserted() {
+	sl.app.queueLogic.onQueueDataInserted()
 }
 
 // Application represents the core application code based on hexagonal architecture
@@ -50,18 +60,23 @@ type Application struct {
 	Services Services // expose to the drivers adapters
 	Admin    Admin    // expose to the drivers adapters
 	BBs      BBs      // expose to the drivers adapters
+	System   System   // expose to the drivers adapters
 	logger   *logs.Logger
 
 	storage  Storage
 	firebase Firebase
 	mailer   Mailer
 	core     Core
+	clock    Clock
 
 	//gueue logic
-	queueLogic queueLogic
+	queueLogic *queueLogic
 
 	//delete data logic
 	deleteDataLogic deleteDataLogic
+
+	//recurring messages logic
+	scheduleLogic *scheduleLogic
 }
 
 // Start starts the core part of the application
@@ -72,23 +87,36 @@ func (app *Application) Start() {
 
 	app.queueLogic.start()
 	app.deleteDataLogic.start()
+	app.scheduleLogic.start()
+}
+
+// Stop stops the core part of the application. It waits for the in-flight notifications to be sent until the context is done.
+func (app *Application) Stop(ctx context.Context) error {
+	app.deleteDataLogic.stop()
+	app.scheduleLogic.stop()
+
+	return app.queueLogic.stop(ctx)
 }
 
 // NewApplication creates new Application
-func NewApplication(version string, build string, storage Storage, firebase Firebase, mailer *mailer.Adapter, logger *logs.Logger, core *core.Adapter) *Application {
+func NewApplication(version string, build string, storage Storage, firebase Firebase, mailer *mailer.Adapter, logger *logs.Logger, core Core,
+	queueConfig model.QueueConfig, clock Clock) *Application {
 
 	timerDone := make(chan bool)
-	queueLogic := queueLogic{logger: logger, storage: storage, firebase: firebase, timerDone: timerDone}
+	queueLogic := newQueueLogic(logger, storage, firebase, clock, timerDone, queueConfig)
 
-	deleteDataLogic := deleteDataLogic{logger: *logger, coreAdapter: core, storage: storage}
+	deleteDataLogic := deleteDataLogic{logger: *logger, coreAdapter: core, storage: storage, clock: clock, timerDone: make(chan bool)}
 
 	application := Application{version: version, build: build, storage: storage, firebase: firebase,
-		mailer: mailer, logger: logger, core: core, queueLogic: queueLogic, deleteDataLogic: deleteDataLogic}
+		mailer: mailer, logger: logger, core: core, clock: clock, queueLogic: queueLogic, deleteDataLogic: deleteDataLogic}
 
 	//add the drivers ports/interfaces
 	application.Services = &servicesImpl{app: &application}
 	application.Admin = &adminImpl{app: &application}
 	application.BBs = &bbsImpl{app: &application}
+	application.System = &systemImpl{app: &application}
+
+	application.scheduleLogic = newScheduleLogic(logger, &application)
 
 	return &application
 }
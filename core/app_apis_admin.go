@@ -14,7 +14,15 @@
 
 package core
 
-import "notifications/core/model"
+import (
+	"context"
+	"errors"
+	"notifications/core/model"
+	"notifications/driven/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 func (app *Application) adminGetMessagesStats(orgID string, appID string, adminAccountID string, source string, offset *int64, limit *int64, order *string) (map[int][]interface{}, error) {
 	//1. find the messages
@@ -57,3 +65,244 @@ func (app *Application) adminGetMessagesStats(orgID string, appID string, adminA
 	}
 	return result, nil
 }
+
+func (app *Application) adminGetDeliveryAttempts(orgID string, appID string, messageID *string, userID *string, offset *int64, limit *int64) ([]model.DeliveryAttempt, error) {
+	if messageID == nil && userID == nil {
+		return nil, errors.New("message id or user id is required")
+	}
+	return app.storage.FindDeliveryAttempts(orgID, appID, messageID, userID, offset, limit)
+}
+
+func (app *Application) adminGetQueue(orgID string, appID string, messageID *string) (*model.QueueOverview, error) {
+	pause, err := app.storage.FindQueuePause(orgID, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	//the org/app pending items
+	messages, err := app.storage.FindQueueDataPending(orgID, appID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	pending := model.QueuePending{Messages: messages}
+	for _, message := range messages {
+		pending.Count += message.Count
+		if pending.NextTime == nil || message.NextTime.Before(*pending.NextTime) {
+			messageNextTime := message.NextTime
+			pending.NextTime = &messageNextTime
+		}
+	}
+
+	return &model.QueueOverview{Pause: pause, Pending: pending}, nil
+}
+
+func (app *Application) adminPauseQueue(orgID string, appID string, accountID string) (*model.QueuePause, error) {
+	pause := model.QueuePause{OrgID: orgID, AppID: appID, ID: uuid.NewString(), PausedBy: accountID, DateCreated: app.clock.Now().UTC()}
+	err := app.storage.CreateQueuePauseIfMissing(pause)
+	if err != nil {
+		return nil, err
+	}
+
+	//it may have been paused before
+	return app.storage.FindQueuePause(orgID, appID)
+}
+
+func (app *Application) adminResumeQueue(orgID string, appID string) (bool, error) {
+	resumed, err := app.storage.DeleteQueuePause(orgID, appID)
+	if err != nil {
+		return false, err
+	}
+
+	//process the items which have become due while paused
+	if resumed {
+		go app.queueLogic.onQueuePush()
+	}
+	return resumed, nil
+}
+
+func (app *Application) adminGetQueueDeadLetters(orgID string, appID string, messageID *string, offset *int64, limit *int64) ([]model.QueueDeadLetter, error) {
+	if limit == nil {
+		defaultLimit := int64(100)
+		limit = &defaultLimit
+	}
+	return app.storage.FindQueueDeadLettersWithContext(nil, orgID, appID, nil, messageID, offset, limit)
+}
+
+func (app *Application) adminGetQueueDeadLetter(orgID string, appID string, id string) (*model.QueueDeadLetter, error) {
+	deadLetters, err := app.storage.FindQueueDeadLettersWithContext(nil, orgID, appID, []string{id}, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(deadLetters) == 0 {
+		return nil, nil
+	}
+	return &deadLetters[0], nil
+}
+
+// queueDeadLettersReplayPageSize is the max number of dead letters replayed in one transaction
+const queueDeadLettersReplayPageSize int64 = 100
+
+func (app *Application) adminReplayQueueDeadLetters(orgID string, appID string, id *string, messageID *string, limit *int64) (int, error) {
+	maxCount := int64(1000)
+	if limit != nil {
+		maxCount = *limit
+	}
+
+	//replay in pages so that every transaction stays small, the replayed dead letters are removed so the next page starts from the beginning
+	replayed := 0
+	var err error
+	for int64(replayed) < maxCount {
+		pageSize := min(queueDeadLettersReplayPageSize, maxCount-int64(replayed))
+		var count int
+		count, err = app.replayQueueDeadLettersPage(orgID, appID, id, messageID, pageSize)
+		if err != nil {
+			break
+		}
+		replayed += count
+		if int64(count) < pageSize {
+			break //no more dead letters
+		}
+	}
+
+	//notify the queue that new items are added, also for the pages replayed before an error
+	if replayed > 0 {
+		go app.queueLogic.onQueuePush()
+	}
+
+	return replayed, err
+}
+
+func (app *Application) replayQueueDeadLettersPage(orgID string, appID string, id *string, messageID *string, pageSize int64) (int, error) {
+	replayed := 0
+
+	//in transaction
+	transaction := func(context storage.TransactionContext) error {
+		//find the dead letters
+		deadLetters, err := app.storage.FindQueueDeadLettersWithContext(context, orgID, appID, app.getQueueDeadLettersIDs(id), messageID, nil, &pageSize)
+		if err != nil {
+			return err
+		}
+		if len(deadLetters) == 0 {
+			return nil //nothing to replay
+		}
+
+		//put them back in the queue as new items
+		now := app.clock.Now()
+		queueItems := make([]model.QueueItem, len(deadLetters))
+		deadLettersIDs := make([]string, len(deadLetters))
+		for i, deadLetter := range deadLetters {
+			deadLettersIDs[i] = deadLetter.ID
+			queueItems[i] = model.QueueItem{OrgID: deadLetter.OrgID, AppID: deadLetter.AppID, ID: uuid.NewString(),
+				MessageID: deadLetter.MessageID, MessageRecipientID: deadLetter.MessageRecipientID, UserID: deadLetter.UserID,
+				Subject: deadLetter.Subject, Body: deadLetter.Body, Data: deadLetter.Data, Localizations: deadLetter.Localizations,
+				Time: now, Priority: deadLetter.Priority, ExpiresAt: deadLetter.ExpiresAt,
+				CollapseKey: deadLetter.CollapseKey, Topic: deadLetter.Topic, DigestOf: deadLetter.DigestOf, PushOptions: deadLetter.PushOptions,
+				Silent: deadLetter.Silent, Tokens: deadLetter.Tokens}
+		}
+		err = app.storage.InsertQueueDataItemsWithContext(context, queueItems)
+		if err != nil {
+			return err
+		}
+
+		//remove the dead letters
+		_, err = app.storage.DeleteQueueDeadLettersWithContext(context, orgID, appID, deadLettersIDs, nil)
+		if err != nil {
+			return err
+		}
+
+		replayed = len(queueItems)
+		return nil
+	}
+
+	//perform transactions
+	err := app.storage.PerformTransaction(transaction, 10000) //10 seconds timeout
+	if err != nil {
+		return 0, err
+	}
+	return replayed, nil
+}
+
+func (app *Application) adminDeleteQueueDeadLetters(orgID string, appID string, id *string, messageID *string) (int64, error) {
+	return app.storage.DeleteQueueDeadLettersWithContext(nil, orgID, appID, app.getQueueDeadLettersIDs(id), messageID)
+}
+
+func (app *Application) getQueueDeadLettersIDs(id *string) []string {
+	if id == nil {
+		return nil //all
+	}
+	return []string{*id}
+}
+
+func (app *Application) adminCreateMessageSchedule(orgID string, appID string, cron string, timeZone string, message model.MessageTemplate) (*model.MessageSchedule, error) {
+	return app.sharedCreateMessageSchedule(orgID, appID, cron, timeZone, message)
+}
+
+func (app *Application) adminGetMessageSchedules(orgID string, appID string, status *string, offset *int64, limit *int64) ([]model.MessageSchedule, error) {
+	return app.storage.FindMessageSchedules(orgID, appID, status, offset, limit)
+}
+
+func (app *Application) adminGetMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error) {
+	schedule, err := app.storage.FindMessageSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil || schedule.OrgID != orgID || schedule.AppID != appID {
+		return nil, nil
+	}
+	return schedule, nil
+}
+
+func (app *Application) adminPauseMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error) {
+	schedule, err := app.adminGetMessageSchedule(orgID, appID, id)
+	if err != nil || schedule == nil {
+		return nil, err
+	}
+	return app.sharedUpdateMessageScheduleStatus(schedule, model.MessageScheduleStatusPaused)
+}
+
+func (app *Application) adminResumeMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error) {
+	schedule, err := app.adminGetMessageSchedule(orgID, appID, id)
+	if err != nil || schedule == nil {
+		return nil, err
+	}
+	return app.sharedUpdateMessageScheduleStatus(schedule, model.MessageScheduleStatusActive)
+}
+
+func (app *Application) adminDeleteMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error) {
+	schedule, err := app.adminGetMessageSchedule(orgID, appID, id)
+	if err != nil || schedule == nil {
+		return nil, err
+	}
+	return app.sharedDeleteMessageSchedule(schedule)
+}
+
+func (app *Application) adminGetMessage(orgID string, appID string, id string) (*model.Message, error) {
+	messages, err := app.storage.FindMessagesWithContext(context.Background(), []string{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 || messages[0].OrgID != orgID || messages[0].AppID != appID {
+		return nil, nil
+	}
+	return &messages[0], nil
+}
+
+func (app *Application) adminRescheduleMessage(orgID string, appID string, id string, messageTime time.Time) (*model.Message, error) {
+	message, err := app.adminGetMessage(orgID, appID, id)
+	if err != nil || message == nil {
+		return nil, err
+	}
+	return app.sharedRescheduleMessage(message, messageTime)
+}
+
+func (app *Application) adminCancelMessage(orgID string, appID string, id string, cancelledBy model.Sender) (*model.MessageCancellation, error) {
+	message, err := app.adminGetMessage(orgID, appID, id)
+	if err != nil || message == nil {
+		return nil, err
+	}
+	return app.sharedCancelMessage(message, cancelledBy)
+}
+
+func (app *Application) adminGetMessageCancellations(orgID string, appID string, messageID *string, offset *int64, limit *int64) ([]model.MessageCancellation, error) {
+	return app.storage.FindMessageCancellations(orgID, appID, messageID, offset, limit)
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"notifications/core/model"
+	"testing"
+)
+
+func TestReplayQueueDeadLettersInBoundedTransactions(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	for i := 0; i < 250; i++ {
+		ta.storage.deadLetter = append(ta.storage.deadLetter, model.QueueDeadLetter{OrgID: "org", AppID: "app",
+			ID: fmt.Sprintf("dead_letter_%d", i), MessageID: "message", UserID: "u1", Subject: "subject"})
+	}
+
+	limit := int64(220)
+	replayed, err := ta.app.adminReplayQueueDeadLetters("org", "app", nil, nil, &limit)
+	if err != nil {
+		t.Fatalf("error on replay - %s", err)
+	}
+	if replayed != 220 {
+		t.Errorf("replayed %d, expected the limit", replayed)
+	}
+	if ta.storage.transactionsCount != 3 {
+		t.Errorf("%d transactions, expected 3 - 100, 100 and 20 dead letters", ta.storage.transactionsCount)
+	}
+	if len(ta.storage.deadLetter) != 30 {
+		t.Errorf("%d dead letters left, expected 30", len(ta.storage.deadLetter))
+	}
+
+	replayed, err = ta.app.adminReplayQueueDeadLetters("org", "app", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("error on replay - %s", err)
+	}
+	if replayed != 30 || len(ta.storage.deadLetter) != 0 {
+		t.Errorf("replayed %d with %d left, expected all of them", replayed, len(ta.storage.deadLetter))
+	}
+}
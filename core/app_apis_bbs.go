@@ -15,6 +15,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"notifications/core/model"
@@ -123,18 +124,20 @@ func (app *Application) bbsAddRecipients(l *logs.Log, serviceAccountID string, m
 		//create recipients objects
 		recipients := make([]model.MessageRecipient, len(inputRecipients))
 		for i, item := range inputRecipients {
-			now := time.Now()
+			now := app.clock.Now()
 			current := model.MessageRecipient{OrgID: message.OrgID, AppID: message.AppID,
 				ID: uuid.NewString(), UserID: item.UserID, MessageID: message.ID, Mute: item.Mute,
 				Read: false, Message: message, DateCreated: &now}
 			recipients[i] = current
 		}
 
-		//insert recipients
-		err = app.storage.InsertMessagesRecipientsWithContext(context, recipients)
-		if err != nil {
-			fmt.Printf("error on inserting a recipient: %s", err)
-			return err
+		//insert recipients - the silent messages are not in the inbox unless requested
+		if message.IsInInbox() {
+			err = app.storage.InsertMessagesRecipientsWithContext(context, recipients)
+			if err != nil {
+				fmt.Printf("error on inserting a recipient: %s", err)
+				return err
+			}
 		}
 
 		//create the notifications queue items and store them in the queue
@@ -229,3 +232,71 @@ func (app *Application) bbsDeleteRecipients(l *logs.Log, serviceAccountID string
 
 	return nil
 }
+
+func (app *Application) bbsCreateMessageSchedule(orgID string, appID string, cron string, timeZone string, message model.MessageTemplate) (*model.MessageSchedule, error) {
+	return app.sharedCreateMessageSchedule(orgID, appID, cron, timeZone, message)
+}
+
+// bbsGetMessageSchedule gives the schedule only if the service account has created it
+func (app *Application) bbsGetMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error) {
+	schedule, err := app.storage.FindMessageSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil || !schedule.IsSender(serviceAccountID) {
+		return nil, nil
+	}
+	return schedule, nil
+}
+
+func (app *Application) bbsPauseMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error) {
+	schedule, err := app.bbsGetMessageSchedule(serviceAccountID, id)
+	if err != nil || schedule == nil {
+		return nil, err
+	}
+	return app.sharedUpdateMessageScheduleStatus(schedule, model.MessageScheduleStatusPaused)
+}
+
+func (app *Application) bbsResumeMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error) {
+	schedule, err := app.bbsGetMessageSchedule(serviceAccountID, id)
+	if err != nil || schedule == nil {
+		return nil, err
+	}
+	return app.sharedUpdateMessageScheduleStatus(schedule, model.MessageScheduleStatusActive)
+}
+
+func (app *Application) bbsDeleteMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error) {
+	schedule, err := app.bbsGetMessageSchedule(serviceAccountID, id)
+	if err != nil || schedule == nil {
+		return nil, err
+	}
+	return app.sharedDeleteMessageSchedule(schedule)
+}
+
+// bbsGetMessage gives the message only if the service account has sent it
+func (app *Application) bbsGetMessage(serviceAccountID string, messageID string) (*model.Message, error) {
+	messages, err := app.storage.FindMessagesWithContext(context.Background(), []string{messageID})
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 || !app.isSenderValid(serviceAccountID, messages[0]) {
+		return nil, nil
+	}
+	return &messages[0], nil
+}
+
+func (app *Application) bbsRescheduleMessage(serviceAccountID string, messageID string, messageTime time.Time) (*model.Message, error) {
+	message, err := app.bbsGetMessage(serviceAccountID, messageID)
+	if err != nil || message == nil {
+		return nil, err
+	}
+	return app.sharedRescheduleMessage(message, messageTime)
+}
+
+func (app *Application) bbsCancelMessage(serviceAccountID string, messageID string, cancelledBy model.Sender) (*model.MessageCancellation, error) {
+	message, err := app.bbsGetMessage(serviceAccountID, messageID)
+	if err != nil || message == nil {
+		return nil, err
+	}
+	return app.sharedCancelMessage(message, cancelledBy)
+}
@@ -179,8 +179,8 @@ func (app *Application) createMessage(inputMessage model.InputMessage) (*model.M
 	return &messages[0], nil //return only one
 }
 
-func (app *Application) getMessagesRecipientsDeep(orgID string, appID string, userID *string, read *bool, mute *bool, messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string, offset *int64, limit *int64, order *string) ([]model.MessageRecipient, error) {
-	return app.storage.FindMessagesRecipientsDeep(orgID, appID, userID, read, mute, messageIDs, startDateEpoch, endDateEpoch, filterTopic, offset, limit, order)
+func (app *Application) getMessagesRecipientsDeep(orgID string, appID string, userID *string, read *bool, mute *bool, messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string, includeExpired bool, offset *int64, limit *int64, order *string) ([]model.MessageRecipient, error) {
+	return app.storage.FindMessagesRecipientsDeep(orgID, appID, userID, read, mute, messageIDs, startDateEpoch, endDateEpoch, filterTopic, includeExpired, offset, limit, order)
 }
 
 func (app *Application) getMessagesStats(orgID string, appID string, userID string) (*model.MessagesStats, error) {
@@ -238,11 +238,23 @@ func (app *Application) updateReadMessage(orgID string, appID string, ID string,
 	if updateReadMessage == nil {
 		return nil, nil
 	}
+
+	//update the badge on the user devices
+	go app.queueLogic.sendBadgeUpdate(orgID, appID, userID)
+
 	return updateReadMessage, nil
 }
 
 func (app *Application) updateAllUserMessagesRead(orgID string, appID string, userID string, read bool) error {
-	return app.storage.UpdateAllUserMessagesRead(context.Background(), orgID, appID, userID, read)
+	err := app.storage.UpdateAllUserMessagesRead(context.Background(), orgID, appID, userID, read)
+	if err != nil {
+		return err
+	}
+
+	//update the badge on the user devices
+	go app.queueLogic.sendBadgeUpdate(orgID, appID, userID)
+
+	return nil
 }
 
 func (app *Application) deleteUserMessage(orgID string, appID string, userID string, messageID string) error {
@@ -265,8 +277,9 @@ func (app *Application) findUserByID(orgID string, appID string, userID string)
 	return app.storage.FindUserByID(orgID, appID, userID)
 }
 
-func (app *Application) updateUserByID(orgID string, appID string, userID string, notificationsDisabled bool) (*model.User, error) {
-	return app.storage.UpdateUserByID(orgID, appID, userID, notificationsDisabled)
+func (app *Application) updateUserByID(orgID string, appID string, userID string, notificationsDisabled bool, quietHours *model.QuietHours, timeZone *string,
+	digest *model.DigestSettings) (*model.User, error) {
+	return app.storage.UpdateUserByID(orgID, appID, userID, notificationsDisabled, quietHours, timeZone, digest)
 }
 
 func (app *Application) deleteUserWithID(orgID string, appID string, userID string) error {
@@ -17,19 +17,41 @@ package core
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"notifications/core/model"
 	"notifications/driven/storage"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rokwire/rokwire-building-block-sdk-go/utils/errors"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logutils"
 )
 
+// ErrMessageNotPending is given when a message which has already been sent is rescheduled or cancelled
+var ErrMessageNotPending = errors.New("the message is not pending")
+
 func (app *Application) sharedCreateMessages(imMessages []model.InputMessage) ([]model.Message, error) {
 
 	if len(imMessages) == 0 {
 		return nil, errors.New("no data")
 	}
+	for _, im := range imMessages {
+		if im.CollapseKey != nil && len(*im.CollapseKey) > model.MessageCollapseKeyMaxLength {
+			return nil, errors.ErrorData(logutils.StatusInvalid, "collapse key", &logutils.FieldArgs{"collapse_key": *im.CollapseKey})
+		}
+		if im.ExpiresAt != nil && !im.ExpiresAt.After(im.Time) {
+			return nil, errors.ErrorData(logutils.StatusInvalid, "expires at", &logutils.FieldArgs{"expires_at": *im.ExpiresAt, "time": im.Time})
+		}
+		if err := sharedValidatePushOptions(im.PushOptions); err != nil {
+			return nil, err
+		}
+		if !model.IsValidMessageDeliveryMode(im.DeliveryMode) {
+			return nil, errors.ErrorData(logutils.StatusInvalid, "delivery mode", &logutils.FieldArgs{"delivery_mode": im.DeliveryMode})
+		}
+		if _, empty := im.Localizations[""]; empty {
+			return nil, errors.ErrorData(logutils.StatusInvalid, "localizations", logutils.StringArgs("empty locale"))
+		}
+	}
 
 	var err error
 	resultMessages := []model.Message{}
@@ -37,69 +59,120 @@ func (app *Application) sharedCreateMessages(imMessages []model.InputMessage) ([
 
 	//in transaction
 	transaction := func(context storage.TransactionContext) error {
+		resultMessages, notifyQueue, err = app.sharedCreateMessagesWithContext(context, imMessages)
+		return err
+	}
 
-		allMessages := []model.Message{}
-		allRecipients := []model.MessageRecipient{}
-		allQueueItems := []model.QueueItem{}
+	//perform transactions
+	err = app.storage.PerformTransaction(transaction, 10000) //10 seconds timeout
+	if err != nil {
+		fmt.Printf("error performing create message transaction - %s", err)
+		return nil, err
+	}
 
-		//process every message
-		for _, im := range imMessages {
-			message, recipients, err := app.sharedHandleInputMessage(context, im)
-			if err != nil {
-				fmt.Printf("error on handling a message: %s", err)
-				return err
-			}
-			queueItems := app.sharedCreateQueueItems(*message, recipients)
+	//notify the queue that new items are added
+	if notifyQueue {
+		go app.queueLogic.onQueuePush()
+	}
 
-			allMessages = append(allMessages, *message)
-			allRecipients = append(allRecipients, recipients...)
-			allQueueItems = append(allQueueItems, queueItems...)
-		}
+	return resultMessages, nil
+}
 
-		//store the messages object
-		err = app.storage.InsertMessagesWithContext(context, allMessages)
-		if err != nil {
-			fmt.Printf("error on creating a message: %s", err)
-			return err
-		}
+// sharedCreateMessagesWithContext stores the messages, their recipients and queue items in the transaction.
+// It gives true if queue items have been added so that the queue has to be notified once the transaction is committed.
+func (app *Application) sharedCreateMessagesWithContext(context storage.TransactionContext, imMessages []model.InputMessage) ([]model.Message, bool, error) {
+	allMessages := []model.Message{}
+	allRecipients := []model.MessageRecipient{}
+	allQueueItems := []model.QueueItem{}
 
-		//store recipients
-		err = app.storage.InsertMessagesRecipientsWithContext(context, allRecipients)
+	//process every message
+	for _, im := range imMessages {
+		message, recipients, err := app.sharedHandleInputMessage(context, im)
 		if err != nil {
-			fmt.Printf("error on inserting recipients: %s", err)
-			return err
+			fmt.Printf("error on handling a message: %s", err)
+			return nil, false, err
 		}
+		queueItems := app.sharedCreateQueueItems(*message, recipients)
 
-		//store the notifications queue items in the queue
-		if len(allQueueItems) > 0 {
-			err = app.storage.InsertQueueDataItemsWithContext(context, allQueueItems)
+		//replace the earlier notifications with the same collapse key
+		if message.CollapseKey != nil {
+			err = app.sharedCollapseMessages(context, *message, recipients, im.SupersedePrevious)
 			if err != nil {
-				fmt.Printf("error on inserting queue data items: %s", err)
-				return err
+				fmt.Printf("error on collapsing a message: %s", err)
+				return nil, false, err
 			}
+			allQueueItems, allRecipients = sharedCollapseBatch(allMessages, allQueueItems, allRecipients, *message, recipients, im.SupersedePrevious)
+		}
 
-			//notify the queue that new items are added
-			notifyQueue = true
+		allMessages = append(allMessages, *message)
+		if message.IsInInbox() {
+			allRecipients = append(allRecipients, recipients...)
 		}
+		allQueueItems = append(allQueueItems, queueItems...)
+	}
 
-		resultMessages = allMessages
+	//store the messages object
+	err := app.storage.InsertMessagesWithContext(context, allMessages)
+	if err != nil {
+		fmt.Printf("error on creating a message: %s", err)
+		return nil, false, err
+	}
 
-		return nil
+	//store recipients
+	err = app.storage.InsertMessagesRecipientsWithContext(context, allRecipients)
+	if err != nil {
+		fmt.Printf("error on inserting recipients: %s", err)
+		return nil, false, err
 	}
 
-	//perform transactions
-	err = app.storage.PerformTransaction(transaction, 10000) //10 seconds timeout
+	//store the notifications queue items in the queue
+	if len(allQueueItems) == 0 {
+		return allMessages, false, nil
+	}
+	err = app.storage.InsertQueueDataItemsWithContext(context, allQueueItems)
 	if err != nil {
-		fmt.Printf("error performing create message transaction - %s", err)
-		return nil, err
+		fmt.Printf("error on inserting queue data items: %s", err)
+		return nil, false, err
 	}
 
-	//notify the queue that new items are added
-	if notifyQueue {
-		go app.queueLogic.onQueuePush()
+	return allMessages, true, nil
+}
+
+// sharedValidatePushOptions rejects the push options which FCM does not accept - FCM would reject the whole message
+func sharedValidatePushOptions(pushOptions *model.PushOptions) error {
+	if pushOptions == nil {
+		return nil
+	}
+	if pushOptions.Badge != nil && *pushOptions.Badge < 0 {
+		return errors.ErrorData(logutils.StatusInvalid, "push options", &logutils.FieldArgs{"badge": *pushOptions.Badge})
 	}
+	if pushOptions.ImageURL != nil && !sharedIsAbsoluteURL(*pushOptions.ImageURL, "http", "https") {
+		return errors.ErrorData(logutils.StatusInvalid, "push options", &logutils.FieldArgs{"image_url": *pushOptions.ImageURL})
+	}
+	if pushOptions.WebpushLink != nil && !sharedIsAbsoluteURL(*pushOptions.WebpushLink, "https") {
+		return errors.ErrorData(logutils.StatusInvalid, "push options", &logutils.FieldArgs{"webpush_link": *pushOptions.WebpushLink})
+	}
+	names := map[string]*string{"sound": pushOptions.Sound, "android_channel_id": pushOptions.AndroidChannelID, "apns_category": pushOptions.APNSCategory}
+	for field, name := range names {
+		if name != nil && len(*name) > model.PushOptionsNameMaxLength {
+			return errors.ErrorData(logutils.StatusInvalid, "push options", &logutils.FieldArgs{field: *name})
+		}
+	}
+	return nil
+}
 
-	return resultMessages, nil
+// sharedIsAbsoluteURL checks if the value is an absolute URL with a host and one of the schemes
+func sharedIsAbsoluteURL(value string, schemes ...string) bool {
+	parsed, err := url.ParseRequestURI(value)
+	if err != nil || len(parsed.Host) == 0 {
+		return false
+	}
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			return true
+		}
+	}
+	return false
 }
 
 func (app *Application) sharedHandleInputMessage(context storage.TransactionContext, im model.InputMessage) (*model.Message, []model.MessageRecipient, error) {
@@ -124,10 +197,14 @@ func (app *Application) sharedHandleInputMessage(context storage.TransactionCont
 		im.Data = map[string]string{}
 	}
 	im.Data["message_id"] = *messageID
+	deliveryMode := im.DeliveryMode
+	if len(deliveryMode) == 0 {
+		deliveryMode = model.MessageDeliveryModeAlert
+	}
 	calculatedRecipients := len(recipients)
-	dateCreated := time.Now()
-	message := model.Message{OrgID: im.OrgID, AppID: im.AppID, ID: *messageID, Priority: im.Priority, Time: im.Time,
-		Subject: im.Subject, Sender: im.Sender, Body: im.Body, Data: im.Data, RecipientsCriteriaList: im.RecipientsCriteriaList,
+	dateCreated := app.clock.Now()
+	message := model.Message{OrgID: im.OrgID, AppID: im.AppID, ID: *messageID, Priority: im.Priority, Time: im.Time, ExpiresAt: im.ExpiresAt, CollapseKey: im.CollapseKey,
+		PushOptions: im.PushOptions, DeliveryMode: deliveryMode, SilentInInbox: im.SilentInInbox, Subject: im.Subject, Sender: im.Sender, Body: im.Body, Data: im.Data, Localizations: im.Localizations, RecipientsCriteriaList: im.RecipientsCriteriaList,
 		RecipientAccountCriteria: im.RecipientAccountCriteria, Topic: im.Topic, CalculatedRecipientsCount: &calculatedRecipients, DateCreated: &dateCreated}
 
 	return &message, recipients, nil
@@ -135,6 +212,9 @@ func (app *Application) sharedHandleInputMessage(context storage.TransactionCont
 
 func (app *Application) sharedCreateQueueItems(message model.Message, messageRecipients []model.MessageRecipient) []model.QueueItem {
 	queueItems := []model.QueueItem{}
+	if !message.IsPushed() {
+		return queueItems //inbox only
+	}
 
 	for _, messageRecipient := range messageRecipients {
 		if !messageRecipient.Mute {
@@ -150,13 +230,20 @@ func (app *Application) sharedCreateQueueItems(message model.Message, messageRec
 			subject := message.Subject
 			body := message.Body
 			data := message.Data
+			localizations := message.Localizations
 
 			time := message.Time
 			priority := message.Priority
+			expiresAt := message.ExpiresAt
+			collapseKey := message.CollapseKey
+			topic := message.Topic
+			pushOptions := message.PushOptions
+			silent := message.IsSilent()
 
 			queueItem := model.QueueItem{OrgID: orgID, AppID: appID, ID: id,
 				MessageID: messageID, MessageRecipientID: messageRecipientID, UserID: userID,
-				Subject: subject, Body: body, Data: data, Time: time, Priority: priority}
+				Subject: subject, Body: body, Data: data, Localizations: localizations, Time: time, Priority: priority, ExpiresAt: expiresAt,
+				CollapseKey: collapseKey, Topic: topic, PushOptions: pushOptions, Silent: silent}
 
 			queueItems = append(queueItems, queueItem)
 		}
@@ -165,6 +252,64 @@ func (app *Application) sharedCreateQueueItems(message model.Message, messageRec
 	return queueItems
 }
 
+// sharedCollapseMessages removes the still queued items of the earlier messages with the same collapse key for the message recipients.
+// It also marks the earlier inbox entries as superseded if requested.
+func (app *Application) sharedCollapseMessages(context storage.TransactionContext, message model.Message, recipients []model.MessageRecipient, supersede bool) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	usersIDs := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		usersIDs[i] = recipient.UserID
+	}
+
+	err := app.storage.DeleteQueueDataForCollapseKeyWithContext(context, message.OrgID, message.AppID, *message.CollapseKey, usersIDs)
+	if err != nil {
+		return err
+	}
+
+	if supersede {
+		err = app.storage.SupersedeMessagesRecipientsWithContext(context, message.OrgID, message.AppID, *message.CollapseKey, usersIDs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sharedCollapseBatch does the same as sharedCollapseMessages for the earlier messages created in the same batch as they are not stored yet
+func sharedCollapseBatch(messages []model.Message, queueItems []model.QueueItem, recipients []model.MessageRecipient,
+	message model.Message, messageRecipients []model.MessageRecipient, supersede bool) ([]model.QueueItem, []model.MessageRecipient) {
+	users := map[string]bool{}
+	for _, recipient := range messageRecipients {
+		users[recipient.UserID] = true
+	}
+
+	resultItems := []model.QueueItem{}
+	for _, item := range queueItems {
+		if item.OrgID == message.OrgID && item.AppID == message.AppID && item.CollapseKey != nil && *item.CollapseKey == *message.CollapseKey && users[item.UserID] {
+			continue //superseded by the message
+		}
+		resultItems = append(resultItems, item)
+	}
+
+	if supersede {
+		collapsedMessages := map[string]bool{}
+		for _, current := range messages {
+			if current.OrgID == message.OrgID && current.AppID == message.AppID && current.CollapseKey != nil && *current.CollapseKey == *message.CollapseKey {
+				collapsedMessages[current.ID] = true
+			}
+		}
+		for i, recipient := range recipients {
+			if collapsedMessages[recipient.MessageID] && users[recipient.UserID] {
+				recipients[i].Superseded = true
+			}
+		}
+	}
+
+	return resultItems, recipients
+}
+
 func (app *Application) sharedCalculateRecipients(context storage.TransactionContext,
 	orgID string, appID string,
 	subject string, body string,
@@ -173,7 +318,7 @@ func (app *Application) sharedCalculateRecipients(context storage.TransactionCon
 
 	messageRecipients := []model.MessageRecipient{}
 	checkCriteria := true
-	now := time.Now()
+	now := app.clock.Now()
 
 	// recipients from message
 	if len(recipients) > 0 {
@@ -304,6 +449,9 @@ func (app *Application) sharedSendMail(toEmail string, subject string, body stri
 
 func (app *Application) sharedCreateRecipientsQueueItems(message *model.Message, messageRecipients []model.MessageRecipient) []model.QueueItem {
 	queueItems := []model.QueueItem{}
+	if !message.IsPushed() {
+		return queueItems //inbox only
+	}
 
 	for _, messageRecipient := range messageRecipients {
 		if !messageRecipient.Mute {
@@ -315,12 +463,19 @@ func (app *Application) sharedCreateRecipientsQueueItems(message *model.Message,
 			subject := message.Subject
 			body := message.Body
 			data := message.Data
+			localizations := message.Localizations
 			time := message.Time
 			priority := message.Priority
+			expiresAt := message.ExpiresAt
+			collapseKey := message.CollapseKey
+			topic := message.Topic
+			pushOptions := message.PushOptions
+			silent := message.IsSilent()
 
 			queueItem := model.QueueItem{OrgID: orgID, AppID: appID, ID: id,
 				MessageID: messageID, MessageRecipientID: id, UserID: userID, Subject: subject, Body: body,
-				Data: data, Time: time, Priority: priority}
+				Data: data, Localizations: localizations, Time: time, Priority: priority, ExpiresAt: expiresAt, CollapseKey: collapseKey, Topic: topic,
+				PushOptions: pushOptions, Silent: silent}
 
 			queueItems = append(queueItems, queueItem)
 		}
@@ -328,3 +483,152 @@ func (app *Application) sharedCreateRecipientsQueueItems(message *model.Message,
 
 	return queueItems
 }
+
+func (app *Application) sharedCreateMessageSchedule(orgID string, appID string, cron string, timeZone string, message model.MessageTemplate) (*model.MessageSchedule, error) {
+	if message.CollapseKey != nil && len(*message.CollapseKey) > model.MessageCollapseKeyMaxLength {
+		return nil, errors.ErrorData(logutils.StatusInvalid, "collapse key", &logutils.FieldArgs{"collapse_key": *message.CollapseKey})
+	}
+	if err := sharedValidatePushOptions(message.PushOptions); err != nil {
+		return nil, err
+	}
+	if !model.IsValidMessageDeliveryMode(message.DeliveryMode) {
+		return nil, errors.ErrorData(logutils.StatusInvalid, "delivery mode", &logutils.FieldArgs{"delivery_mode": message.DeliveryMode})
+	}
+	if _, empty := message.Localizations[""]; empty {
+		return nil, errors.ErrorData(logutils.StatusInvalid, "localizations", logutils.StringArgs("empty locale"))
+	}
+
+	now := app.clock.Now().UTC()
+	schedule := model.MessageSchedule{OrgID: orgID, AppID: appID, ID: uuid.NewString(), Cron: cron, TimeZone: timeZone,
+		Status: model.MessageScheduleStatusActive, Message: message, DateCreated: now}
+
+	nextRunAt, err := schedule.GetNextRun(now)
+	if err != nil {
+		return nil, errors.WrapErrorData(logutils.StatusInvalid, "message schedule", &logutils.FieldArgs{"cron": cron, "time_zone": timeZone}, err)
+	}
+	schedule.NextRunAt = nextRunAt
+
+	err = app.storage.InsertMessageSchedule(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	//let the scheduler know so that it waits for the new run
+	app.scheduleLogic.onSchedulesUpdated()
+
+	return &schedule, nil
+}
+
+// sharedUpdateMessageScheduleStatus pauses or resumes a schedule. A resumed schedule continues with its first run after now, the missed runs are not made.
+func (app *Application) sharedUpdateMessageScheduleStatus(schedule *model.MessageSchedule, status string) (*model.MessageSchedule, error) {
+	var nextRunAt *time.Time
+	if status == model.MessageScheduleStatusActive {
+		var err error
+		nextRunAt, err = schedule.GetNextRun(app.clock.Now().UTC())
+		if err != nil {
+			return nil, errors.WrapErrorData(logutils.StatusInvalid, "message schedule", &logutils.FieldArgs{"_id": schedule.ID}, err)
+		}
+	}
+
+	err := app.storage.UpdateMessageScheduleStatus(schedule.ID, status, nextRunAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == model.MessageScheduleStatusActive {
+		app.scheduleLogic.onSchedulesUpdated()
+	}
+
+	now := app.clock.Now().UTC()
+	schedule.Status = status
+	schedule.NextRunAt = nextRunAt
+	schedule.DateUpdated = &now
+	return schedule, nil
+}
+
+func (app *Application) sharedDeleteMessageSchedule(schedule *model.MessageSchedule) (*model.MessageSchedule, error) {
+	err := app.storage.DeleteMessageSchedule(schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// sharedRescheduleMessage moves a pending message and its notifications to a new time. The expiration time is moved too so that the message keeps its lifetime.
+func (app *Application) sharedRescheduleMessage(message *model.Message, messageTime time.Time) (*model.Message, error) {
+	if !message.IsPending(app.clock.Now()) {
+		return nil, ErrMessageNotPending
+	}
+
+	var expiresAt *time.Time
+	if message.ExpiresAt != nil {
+		value := messageTime.Add(message.ExpiresAt.Sub(message.Time))
+		expiresAt = &value
+	}
+
+	//in transaction
+	transaction := func(context storage.TransactionContext) error {
+		err := app.storage.UpdateMessageTimeWithContext(context, message.ID, messageTime, expiresAt)
+		if err != nil {
+			return err
+		}
+
+		return app.storage.UpdateQueueDataTimeForMessageWithContext(context, message.ID, messageTime, expiresAt)
+	}
+
+	//perform transactions
+	err := app.storage.PerformTransaction(transaction, 10000) //10 seconds timeout
+	if err != nil {
+		return nil, err
+	}
+
+	//let the queue know so that the timer is set for the new time
+	go app.queueLogic.onQueuePush()
+
+	now := app.clock.Now().UTC()
+	message.Time = messageTime
+	message.ExpiresAt = expiresAt
+	message.DateUpdated = &now
+	return message, nil
+}
+
+// sharedCancelMessage removes a pending message together with its recipients and notifications. It keeps a cancellation record of it.
+func (app *Application) sharedCancelMessage(message *model.Message, cancelledBy model.Sender) (*model.MessageCancellation, error) {
+	if !message.IsPending(app.clock.Now()) {
+		return nil, ErrMessageNotPending
+	}
+
+	var cancellation *model.MessageCancellation
+	//in transaction
+	transaction := func(context storage.TransactionContext) error {
+		queueItemsCount, err := app.storage.CountQueueDataForMessageWithContext(context, message.ID)
+		if err != nil {
+			return err
+		}
+
+		err = app.storage.DeleteQueueDataForMessagesWithContext(context, []string{message.ID})
+		if err != nil {
+			return err
+		}
+		err = app.storage.DeleteMessagesRecipientsForMessagesWithContext(context, []string{message.ID})
+		if err != nil {
+			return err
+		}
+		err = app.storage.DeleteMessagesWithContext(context, []string{message.ID})
+		if err != nil {
+			return err
+		}
+
+		cancellation = &model.MessageCancellation{OrgID: message.OrgID, AppID: message.AppID, ID: uuid.NewString(), MessageID: message.ID,
+			Message: *message, QueueItemsCount: int(queueItemsCount), CancelledBy: cancelledBy, DateCreated: app.clock.Now().UTC()}
+		return app.storage.InsertMessageCancellationWithContext(context, *cancellation)
+	}
+
+	//perform transactions
+	err := app.storage.PerformTransaction(transaction, 10000) //10 seconds timeout
+	if err != nil {
+		return nil, err
+	}
+
+	return cancellation, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"notifications/core/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/errors"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logutils"
+)
+
+// isInvalidDataError says if the error is the validation error for the data type
+func isInvalidDataError(err error, dataType logutils.MessageDataType) bool {
+	expected := strings.ToLower(logutils.MessageData(logutils.StatusInvalid, dataType, nil))
+	return err != nil && strings.HasPrefix(errors.Root(err), expected)
+}
+
+func TestCreateMessagesRejectsInvalidExpiration(t *testing.T) {
+	before := testNow.Add(-time.Minute)
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+	}{
+		{name: "before the message time", expiresAt: &before},
+		{name: "at the message time", expiresAt: &testNow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp(t, testNow)
+			message := model.InputMessage{OrgID: "org", AppID: "app", Time: testNow, ExpiresAt: tt.expiresAt,
+				Subject: "subject", Body: "body", DeliveryMode: model.MessageDeliveryModeAlert}
+
+			_, err := ta.app.sharedCreateMessages([]model.InputMessage{message})
+			if !isInvalidDataError(err, "expires at") {
+				t.Errorf("error %v, expected invalid expires at", err)
+			}
+		})
+	}
+}
+
+func TestCreateMessagesRejectsInvalidPushOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		pushOptions model.PushOptions
+	}{
+		{name: "unparseable image url", pushOptions: model.PushOptions{ImageURL: stringPointer("not a url")}},
+		{name: "relative image url", pushOptions: model.PushOptions{ImageURL: stringPointer("/images/a.png")}},
+		{name: "http webpush link", pushOptions: model.PushOptions{WebpushLink: stringPointer("http://example.com/a")}},
+		{name: "relative webpush link", pushOptions: model.PushOptions{WebpushLink: stringPointer("/a")}},
+		{name: "long sound", pushOptions: model.PushOptions{Sound: stringPointer(strings.Repeat("s", model.PushOptionsNameMaxLength+1))}},
+		{name: "long android channel", pushOptions: model.PushOptions{AndroidChannelID: stringPointer(strings.Repeat("c", model.PushOptionsNameMaxLength+1))}},
+		{name: "long apns category", pushOptions: model.PushOptions{APNSCategory: stringPointer(strings.Repeat("c", model.PushOptionsNameMaxLength+1))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp(t, testNow)
+			pushOptions := tt.pushOptions
+
+			message := model.InputMessage{OrgID: "org", AppID: "app", Time: testNow, PushOptions: &pushOptions,
+				Subject: "subject", Body: "body", DeliveryMode: model.MessageDeliveryModeAlert}
+			_, err := ta.app.sharedCreateMessages([]model.InputMessage{message})
+			if !isInvalidDataError(err, "push options") {
+				t.Errorf("error %v on creating the message, expected invalid push options", err)
+			}
+
+			template := model.MessageTemplate{PushOptions: &pushOptions, Subject: "subject", Body: "body", DeliveryMode: model.MessageDeliveryModeAlert}
+			_, err = ta.app.sharedCreateMessageSchedule("org", "app", "0 9 * * *", "UTC", template)
+			if !isInvalidDataError(err, "push options") {
+				t.Errorf("error %v on creating the schedule, expected invalid push options", err)
+			}
+		})
+	}
+}
+
+func TestValidatePushOptionsAcceptsValidOptions(t *testing.T) {
+	pushOptions := model.PushOptions{Sound: stringPointer("default"), ImageURL: stringPointer("https://example.com/a.png"),
+		AndroidChannelID: stringPointer("news"), APNSCategory: stringPointer("reply"), WebpushLink: stringPointer("https://example.com/a")}
+	if err := sharedValidatePushOptions(&pushOptions); err != nil {
+		t.Errorf("error %v on validating valid push options", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"notifications/core/model"
+	"time"
+)
+
+func (app *Application) systemGetQueue() (*model.QueueState, error) {
+	partitions, err := app.storage.FindQueues()
+	if err != nil {
+		return nil, err
+	}
+
+	//the queue is processed when its first upcoming item is due
+	pauses, err := app.storage.FindQueuePauses()
+	if err != nil {
+		return nil, err
+	}
+	upcoming, err := app.storage.FindQueueData(nil, 0, 0, false, nil, pauses, 1)
+	if err != nil {
+		return nil, err
+	}
+	var nextTime *time.Time
+	if len(upcoming) > 0 {
+		nextTime = &upcoming[0].Time
+	}
+
+	return &model.QueueState{Partitions: partitions, NextTime: nextTime}, nil
+}
+
+func (app *Application) systemUpdateQueue(processItemsCount int) error {
+	if processItemsCount <= 0 {
+		return errors.New("process items count must be positive")
+	}
+
+	partitions, err := app.storage.FindQueues()
+	if err != nil {
+		return err
+	}
+	for _, partition := range partitions {
+		err = app.storage.UpdateQueueProcessItemsCount(partition.ID, processItemsCount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (app *Application) systemGetQueueLeases() ([]model.Queue, error) {
+	return app.storage.FindQueues()
+}
+
+func (app *Application) systemUpdateQueuePartition(id string, processItemsCount int) error {
+	if processItemsCount <= 0 {
+		return errors.New("process items count must be positive")
+	}
+	return app.storage.UpdateQueueProcessItemsCount(id, processItemsCount)
+}
+
+func (app *Application) systemProcessQueue() {
+	go app.queueLogic.processQueue()
+}
@@ -29,9 +29,10 @@ type deleteDataLogic struct {
 
 	storage     Storage
 	coreAdapter Core
+	clock       Clock
 
 	//delete data timer
-	dailyDeleteTimer *time.Timer
+	dailyDeleteTimer Timer
 	timerDone        chan bool
 }
 
@@ -43,6 +44,13 @@ func (d deleteDataLogic) start() error {
 	return nil
 }
 
+// stop aborts the delete data timer
+func (d deleteDataLogic) stop() {
+	d.logger.Info("Delete data stop")
+
+	close(d.timerDone)
+}
+
 func (d deleteDataLogic) setupTimerForDelete() {
 	d.logger.Info("Delete data timer")
 
@@ -59,7 +67,7 @@ func (d deleteDataLogic) setupTimerForDelete() {
 	if err != nil {
 		d.logger.Errorf("Error getting location:%s\n", err.Error())
 	}
-	now := time.Now().In(location)
+	now := d.clock.Now().In(location)
 	d.logger.Infof("setupTimerForDelete -> now - hours:%d minutes:%d seconds:%d\n", now.Hour(), now.Minute(), now.Second())
 
 	nowSecondsInDay := 60*60*now.Hour() + 60*now.Minute() + now.Second()
@@ -80,9 +88,9 @@ func (d deleteDataLogic) setupTimerForDelete() {
 	duration := time.Second * time.Duration(durationInSeconds)
 	d.logger.Infof("setupTimerForDelete -> first call after %s", duration)
 
-	d.dailyDeleteTimer = time.NewTimer(duration)
+	d.dailyDeleteTimer = d.clock.NewTimer(duration)
 	select {
-	case <-d.dailyDeleteTimer.C:
+	case <-d.dailyDeleteTimer.C():
 		d.logger.Info("setupTimerForDelete -> delete timer expired")
 		d.dailyDeleteTimer = nil
 
@@ -90,6 +98,7 @@ func (d deleteDataLogic) setupTimerForDelete() {
 	case <-d.timerDone:
 		// timer aborted
 		d.logger.Info("setupTimerForDelete -> delete timer aborted")
+		d.dailyDeleteTimer.Stop()
 		d.dailyDeleteTimer = nil
 	}
 }
@@ -103,9 +112,9 @@ func (d deleteDataLogic) process() {
 	//generate new processing after 24 hours
 	duration := time.Hour * 24
 	d.logger.Infof("Deleting data process -> next call after %s", duration)
-	d.dailyDeleteTimer = time.NewTimer(duration)
+	d.dailyDeleteTimer = d.clock.NewTimer(duration)
 	select {
-	case <-d.dailyDeleteTimer.C:
+	case <-d.dailyDeleteTimer.C():
 		d.logger.Info("Deleting data process -> timer expired")
 		d.dailyDeleteTimer = nil
 
@@ -113,6 +122,7 @@ func (d deleteDataLogic) process() {
 	case <-d.timerDone:
 		// timer aborted
 		d.logger.Info("Deleting data process -> timer aborted")
+		d.dailyDeleteTimer.Stop()
 		d.dailyDeleteTimer = nil
 	}
 }
@@ -145,21 +155,21 @@ func (d deleteDataLogic) processDelete() {
 func (d deleteDataLogic) deleteAppOrgUsersData(appID string, orgID string, accountsIDs []string) {
 
 	// delete the mesages recipients
-	err := d.storage.DeleteMessagesRecipientsForUsers(nil, appID, orgID, accountsIDs)
+	err := d.storage.DeleteMessagesRecipientsForUsers(nil, orgID, appID, accountsIDs)
 	if err != nil {
 		d.logger.Errorf("error deleting the messages recipients for users - %s", err)
 		return
 	}
 
 	// delete the queue data items
-	err = d.storage.DeleteQueueDataForUsers(nil, appID, orgID, accountsIDs)
+	err = d.storage.DeleteQueueDataForUsers(nil, orgID, appID, accountsIDs)
 	if err != nil {
 		d.logger.Errorf("error deleting the queue data items for users - %s", err)
 		return
 	}
 
 	// delete the users
-	err = d.storage.DeleteUsersWithIDs(nil, appID, orgID, accountsIDs)
+	err = d.storage.DeleteUsersWithIDs(nil, orgID, appID, accountsIDs)
 	if err != nil {
 		d.logger.Errorf("error deleting the users - %s", err)
 		return
@@ -175,7 +185,7 @@ func (d deleteDataLogic) getAccountsIDs(memberships []model.DeletedMembership) [
 }
 
 // deleteLogic creates new deleteLogic
-func deleteLogic(coreAdapter Core, logger logs.Logger) deleteDataLogic {
+func deleteLogic(coreAdapter Core, clock Clock, logger logs.Logger) deleteDataLogic {
 	timerDone := make(chan bool)
-	return deleteDataLogic{coreAdapter: coreAdapter, timerDone: timerDone, logger: logger}
+	return deleteDataLogic{coreAdapter: coreAdapter, clock: clock, timerDone: timerDone, logger: logger}
 }
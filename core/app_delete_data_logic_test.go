@@ -0,0 +1,104 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"notifications/core/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeleteDataTimerIsSetFor4AM(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Fatalf("error on loading location - %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{name: "before 4 AM", now: time.Date(2026, 5, 4, 1, 30, 0, 0, chicago), expected: time.Date(2026, 5, 4, 4, 0, 0, 0, chicago)},
+		{name: "just after 4 AM", now: time.Date(2026, 5, 4, 4, 0, 1, 0, chicago), expected: time.Date(2026, 5, 5, 4, 0, 0, 0, chicago)},
+		{name: "after 4 AM", now: time.Date(2026, 5, 4, 5, 0, 0, 0, chicago), expected: time.Date(2026, 5, 5, 4, 0, 0, 0, chicago)},
+		{name: "before midnight", now: time.Date(2026, 5, 4, 23, 59, 59, 0, chicago), expected: time.Date(2026, 5, 5, 4, 0, 0, 0, chicago)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp(t, tt.now.UTC())
+			d := ta.app.deleteDataLogic
+
+			go d.setupTimerForDelete()
+			defer d.stop()
+
+			waitFor(t, "the delete timer", func() bool { return len(ta.clock.ActiveTimers()) == 1 })
+			if timer := ta.clock.ActiveTimers()[0]; !timer.Equal(tt.expected) {
+				t.Errorf("the delete timer expires at %s, expected %s", timer.In(chicago), tt.expected)
+			}
+		})
+	}
+}
+
+func TestDeleteDataDeletesDeletedAccountsDaily(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Fatalf("error on loading location - %s", err)
+	}
+	now := time.Date(2026, 5, 4, 3, 0, 0, 0, chicago)
+
+	ta := newTestApp(t, now.UTC())
+	ta.core.deletedMemberships = []model.DeletedUserData{
+		{OrgID: "org", AppID: "app", Memberships: []model.DeletedMembership{{AccountID: "a1"}, {AccountID: "a2"}}},
+		{OrgID: "org", AppID: "other_app"}, //nothing to delete
+	}
+	d := ta.app.deleteDataLogic
+
+	go d.setupTimerForDelete()
+	defer d.stop()
+
+	waitFor(t, "the delete timer", func() bool { return len(ta.clock.ActiveTimers()) == 1 })
+	ta.clock.Advance(time.Hour)
+
+	expected := []string{"recipients:org_app_a1_a2", "queue_data:org_app_a1_a2", "users:org_app_a1_a2"}
+	waitFor(t, "the data to be deleted", func() bool {
+		ta.storage.mu.Lock()
+		defer ta.storage.mu.Unlock()
+		return len(ta.storage.deletions) == len(expected)
+	})
+	if !reflect.DeepEqual(ta.storage.deletions, expected) {
+		t.Errorf("deleted %v, expected %v", ta.storage.deletions, expected)
+	}
+
+	//the next run is a day later
+	next := []time.Time{now.Add(25 * time.Hour).UTC()}
+	waitFor(t, "the next delete timer", func() bool { return reflect.DeepEqual(ta.clock.ActiveTimers(), next) })
+	if loads := ta.core.getLoadsCount(); loads != 1 {
+		t.Errorf("the deleted memberships have been loaded %d times, expected once", loads)
+	}
+}
+
+func TestDeleteAppOrgUsersDataDeletesTheOrgAppData(t *testing.T) {
+	ta := newTestApp(t, testNow)
+
+	ta.app.deleteDataLogic.deleteAppOrgUsersData("app", "org", []string{"a1"})
+
+	//the storage takes the org id first and the app id second
+	expected := []string{"recipients:org_app_a1", "queue_data:org_app_a1", "users:org_app_a1"}
+	if !reflect.DeepEqual(ta.storage.deletions, expected) {
+		t.Errorf("deleted %v, expected %v", ta.storage.deletions, expected)
+	}
+}
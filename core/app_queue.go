@@ -15,96 +15,440 @@
 package core
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"notifications/core/model"
 	"notifications/driven/storage"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
 )
 
+const (
+	//maxSendAttempts is how many times a queue item is tried before giving up
+	maxSendAttempts int = 5
+	//retryBaseDelay is the delay before the first retry, it is doubled for every next one
+	retryBaseDelay time.Duration = 30 * time.Second
+	//retryMaxDelay is the max delay between retries
+	retryMaxDelay time.Duration = 30 * time.Minute
+
+	defaultQueueWorkersCount int = 20
+	defaultQueueBufferSize   int = 500
+	defaultPartitionsCount   int = 1
+	defaultProcessItemsCount int = 100
+
+	//queueLeaseDuration is how long the queue stays locked by an instance without renewing the lease
+	queueLeaseDuration time.Duration = 60 * time.Second
+	//queueLeaseHeartbeat is how often the lease owner renews the lease
+	queueLeaseHeartbeat time.Duration = 20 * time.Second
+	//queueUnlockRetryDelay is how long the instance waits before retrying to release the lease
+	queueUnlockRetryDelay time.Duration = 200 * time.Millisecond
+
+	//queueFairShareDivisor defines the part of every processed batch(1/divisor) which is given to the oldest due items
+	//regardless of their priority, so that the low priority items are not starved by the high priority ones
+	queueFairShareDivisor int = 5
+
+	//queueInsertsDebounce is how long the queue waits for more inserted items before processing them
+	queueInsertsDebounce time.Duration = 2 * time.Second
+)
+
+// queueJobItem is a queue item together with the tokens it has to be sent to
+type queueJobItem struct {
+	item   model.QueueItem
+	tokens []model.FirebaseToken
+	badge  *int //the user unread messages count
+}
+
+// queueJob is a group of queue items with the same payload waiting for a worker
+type queueJob struct {
+	items []queueJobItem
+}
+
 type queueLogic struct {
 	logger *logs.Logger
 
+	instanceID   string   //the queue lease owner
+	leaseWatches sync.Map //queue partition id -> watch
+
 	storage  Storage
 	firebase Firebase
+	clock    Clock
 
 	//timer
-	queueTimer *time.Timer
+	timerMu    sync.Mutex
+	queueTimer Timer
+	timerAt    time.Time //when the queue timer expires
 	timerDone  chan bool
+
+	//inserted items
+	insertsMu      sync.Mutex
+	insertsPending bool //the queue is going to be processed for the inserted items
+
+	partitionsCount int
+
+	//delivery rate per org/app
+	rateLimiter *rateLimiter
+
+	quietHoursBypassPriority int
+
+	//workers
+	workersCount          int
+	backpressureThreshold int
+	jobs                  chan queueJob
+
+	inFlight     int //queued + in process items
+	inFlightCond *sync.Cond
+
+	//shutdown
+	stopMu       sync.Mutex
+	stopping     bool
+	partitionsWG sync.WaitGroup //partitions in process
+	workersWG    sync.WaitGroup
 }
 
 func (q *queueLogic) start() {
 	q.logger.Info("queueLogic start")
 
+	//start the workers
+	q.workersWG.Add(q.workersCount)
+	for i := 0; i < q.workersCount; i++ {
+		go q.worker()
+	}
+
+	//create the partitions records
+	err := q.createPartitions()
+	if err != nil {
+		q.logger.Errorf("error on creating the queue partitions - %s", err)
+	}
+
+	//set the delivery rate limits
+	firebaseConfs, err := q.storage.LoadFirebaseConfigurations()
+	if err != nil {
+		q.logger.Errorf("error on loading the delivery rate limits - %s", err)
+	} else {
+		q.rateLimiter.setLimits(firebaseConfs)
+	}
+
 	q.processQueue()
 }
 
+// stop stops loading new items from the queue and aborts the timers. It waits until the partitions are released
+// and the in-flight items are sent or until the context is done.
+func (q *queueLogic) stop(ctx context.Context) error {
+	q.logger.Info("queueLogic stop")
+
+	q.stopMu.Lock()
+	if q.stopping {
+		q.stopMu.Unlock()
+		return nil
+	}
+	q.stopping = true
+	q.stopMu.Unlock()
+
+	//abort the timers
+	close(q.timerDone)
+
+	//wait for the partitions to be released
+	if !waitWithContext(ctx, &q.partitionsWG) {
+		return errors.New("timeout on waiting for the queue partitions to be released")
+	}
+
+	//nothing is submitted anymore, so let the workers send the in-flight items and exit
+	close(q.jobs)
+	if !waitWithContext(ctx, &q.workersWG) {
+		q.inFlightCond.L.Lock()
+		inFlight := q.inFlight
+		q.inFlightCond.L.Unlock()
+		return fmt.Errorf("timeout on waiting for %d in-flight items to be sent", inFlight)
+	}
+
+	q.logger.Info("queueLogic stopped")
+	return nil
+}
+
+// enterPartition marks a partition as being processed. It gives false if the queue is stopping.
+func (q *queueLogic) enterPartition() bool {
+	q.stopMu.Lock()
+	defer q.stopMu.Unlock()
+
+	if q.stopping {
+		return false
+	}
+	q.partitionsWG.Add(1)
+	return true
+}
+
+func (q *queueLogic) isStopping() bool {
+	q.stopMu.Lock()
+	defer q.stopMu.Unlock()
+
+	return q.stopping
+}
+
+// createPartitions creates the missing partitions records. The partitions can only be increased, the ones over the configured count are kept.
+func (q *queueLogic) createPartitions() error {
+	queues, err := q.storage.FindQueues()
+	if err != nil {
+		return err
+	}
+
+	processItemsCount := defaultProcessItemsCount
+	if len(queues) > 0 {
+		processItemsCount = queues[0].ProcessItemsCount
+	}
+	for partition := len(queues); partition < q.partitionsCount; partition++ {
+		queue := model.Queue{ID: strconv.Itoa(partition + 1), Partition: partition, Status: model.QueueStatusReady, ProcessItemsCount: processItemsCount}
+		err = q.storage.CreateQueueIfMissing(queue)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *queueLogic) worker() {
+	defer q.workersWG.Done()
+
+	for job := range q.jobs {
+		q.sendNotifications(job)
+		q.onJobDone(job)
+	}
+}
+
+// submitJob gives the job to the workers, it blocks while the buffer is full
+func (q *queueLogic) submitJob(job queueJob) {
+	q.inFlightCond.L.Lock()
+	q.inFlight += len(job.items)
+	q.inFlightCond.L.Unlock()
+
+	q.jobs <- job
+}
+
+func (q *queueLogic) onJobDone(job queueJob) {
+	q.inFlightCond.L.Lock()
+	q.inFlight -= len(job.items)
+	q.inFlightCond.L.Unlock()
+
+	q.inFlightCond.Broadcast()
+}
+
+// waitForCapacity blocks until the in-flight jobs drop below the backpressure threshold
+func (q *queueLogic) waitForCapacity() {
+	q.inFlightCond.L.Lock()
+	defer q.inFlightCond.L.Unlock()
+
+	if q.inFlight >= q.backpressureThreshold {
+		q.logger.Infof("%d items in flight, waiting to drop below %d", q.inFlight, q.backpressureThreshold)
+	}
+	for q.inFlight >= q.backpressureThreshold {
+		q.inFlightCond.Wait()
+	}
+}
+
 func (q *queueLogic) onQueuePush() {
 	q.logger.Info("queueLogic onQueuePush")
 
 	q.processQueue()
 }
 
+// onQueueDataInserted processes the queue when items have been added to it by any instance. The notifications are debounced,
+// so that a large insert triggers one processing run.
+func (q *queueLogic) onQueueDataInserted() {
+	q.insertsMu.Lock()
+	defer q.insertsMu.Unlock()
+
+	if q.insertsPending {
+		return //it will be processed for these items too
+	}
+	q.insertsPending = true
+
+	q.clock.AfterFunc(queueInsertsDebounce, func() {
+		q.insertsMu.Lock()
+		q.insertsPending = false
+		q.insertsMu.Unlock()
+
+		q.logger.Info("queueLogic onQueueDataInserted")
+
+		q.processQueue()
+
+		//the partitions may be processed by other instances, but the timer must still consider the scheduled items
+		err := q.setTimerIfNecessary()
+		if err != nil {
+			q.logger.Errorf("error on setting timer - %s", err)
+		}
+	})
+}
+
 func (q *queueLogic) processQueue() {
 	q.logger.Info("queueLogic processQueue")
 
-	//check if the queue is locked and lock it for processing
-	queueAvailable, queue, err := q.lockQueue()
+	if q.isStopping() {
+		q.logger.Info("the queue is stopping, so do nothing")
+		return
+	}
+
+	//every partition is locked and processed on its own, so many instances can process the queue in parallel
+	partitions, err := q.storage.FindQueues()
 	if err != nil {
-		q.logger.Errorf("error on locking queue - %s", err)
+		q.logger.Errorf("error on finding the queue partitions - %s", err)
 		return
 	}
-	if !*queueAvailable {
-		q.logger.Info("the queue is locked, so do nothing")
+
+	var wg sync.WaitGroup
+	var processed atomic.Bool
+	for _, partition := range partitions {
+		wg.Add(1)
+		go func(partition model.Queue) {
+			defer wg.Done()
+			if q.processPartition(partition.ID, len(partitions)) {
+				processed.Store(true)
+			}
+		}(partition)
+	}
+	wg.Wait()
+
+	if !processed.Load() {
+		return //the partitions are processed by other instances
+	}
+
+	//set timer if there is still items in the queue for scheduled messages
+	err = q.setTimerIfNecessary()
+	if err != nil {
+		q.logger.Errorf("error on setting timer - %s", err)
 		return
 	}
+}
+
+// processPartition processes the partition items if the partition is not locked by another instance. It gives false if it has not been processed.
+func (q *queueLogic) processPartition(queueID string, partitionsCount int) bool {
+	if !q.enterPartition() {
+		return false
+	}
+	defer q.partitionsWG.Done()
 
-	//ensure the queue is always unlocked and protect against panics
+	//check if the partition is locked and lock it for processing
+	queueAvailable, queue, err := q.lockQueue(queueID)
+	if err != nil {
+		q.logger.Errorf("error on locking queue partition %s - %s", queueID, err)
+		return false
+	}
+	if !*queueAvailable {
+		q.logger.Infof("the queue partition %s is locked by %s, so do nothing", queueID, queue.LeaseOwner)
+		q.watchLease(*queue)
+		return false
+	}
+
+	//keep the lease while processing
+	heartbeatDone := make(chan struct{})
+	var leaseLost atomic.Bool
+	go q.renewLease(queue.ID, heartbeatDone, &leaseLost)
+
+	//ensure the partition is always unlocked and protect against panics
 	defer func() {
 		if r := recover(); r != nil {
-			q.logger.Errorf("panic in processQueue: %v", r)
+			q.logger.Errorf("panic in processPartition: %v", r)
 		}
+		close(heartbeatDone)
 		q.unlockQueue(*queue)
 	}()
 
-	//process the queue items until they are available
-	now := time.Now()
+	//process the partition items until they are available
+	now := q.clock.Now()
 	limit := queue.ProcessItemsCount
+	if limit <= 0 {
+		limit = defaultProcessItemsCount
+	}
 	for {
+		//do not load more items until the workers catch up
+		q.waitForCapacity()
+
+		//stop if another instance has taken over the partition
+		if leaseLost.Load() {
+			q.logger.Errorf("the queue partition %s lease has been lost, stop processing", queue.ID)
+			return true
+		}
+
+		//stop on shutdown, the rest of the items stay for the next instance
+		if q.isStopping() {
+			q.logger.Infof("the queue is stopping, stop processing partition %s", queue.ID)
+			return true
+		}
+
 		//get the current items
-		queueItems, err := q.storage.FindQueueData(&now, limit)
+		queueItems, err := q.findDueItems(now, queue.Partition, partitionsCount, limit)
 		if err != nil {
 			q.logger.Errorf("error on finding queue data - %s", err)
-			return
+			return true
 		}
 
 		itemsCount := len(queueItems)
 		if itemsCount == 0 {
-			q.logger.Info("no more items for processing, stop iterating")
+			q.logger.Infof("no more items for processing in partition %s, stop iterating", queue.ID)
 			break //no more items for processing, stop iterating
 		}
 
-		q.logger.Infof("%d items to processes", itemsCount)
+		q.logger.Infof("%d items to processes in partition %s", itemsCount, queue.ID)
 
 		//process the current items
-		err = q.processQueueItem(queueItems)
+		err = q.processQueueItem(queueItems, queue.Partition, partitionsCount)
 		if err != nil {
 			q.logger.Errorf("error on processing items - %s", err)
-			return
+			return true
 		}
 	}
 
-	//set timer if there is still items in the queue for scheduled messages
-	err = q.setTimerIfNecessary()
+	return true
+}
+
+// findDueItems gives the due items of the partition in priority order. A fair share of the items is always given to the oldest ones.
+func (q *queueLogic) findDueItems(now time.Time, partition int, partitionsCount int, limit int) ([]model.QueueItem, error) {
+	fairShare := 0
+	if limit > 1 {
+		fairShare = max(1, limit/queueFairShareDivisor)
+	}
+
+	//the items of the paused org/apps stay in the queue
+	pauses, err := q.storage.FindQueuePauses()
 	if err != nil {
-		q.logger.Errorf("error on setting timer - %s", err)
-		return
+		return nil, err
+	}
+
+	//the most urgent items
+	items, err := q.storage.FindQueueData(&now, partition, partitionsCount, true, nil, pauses, limit-fairShare)
+	if err != nil {
+		return nil, err
+	}
+	if fairShare == 0 || len(items) < limit-fairShare {
+		return items, nil //all due items have been taken
 	}
+
+	//the oldest items which have not been taken yet
+	takenIDs := make([]string, len(items))
+	for i, item := range items {
+		takenIDs[i] = item.ID
+	}
+	oldest, err := q.storage.FindQueueData(&now, partition, partitionsCount, false, takenIDs, pauses, fairShare)
+	if err != nil {
+		return nil, err
+	}
+	return append(items, oldest...), nil
 }
 
 func (q *queueLogic) setTimerIfNecessary() error {
 	//check if there is scheduled messages
-	scheduled, err := q.storage.FindQueueData(nil, 1) //it gives the first upcoming message
+	pauses, err := q.storage.FindQueuePauses()
+	if err != nil {
+		return err
+	}
+	scheduled, err := q.storage.FindQueueData(nil, 0, 0, false, nil, pauses, 1) //it gives the first upcoming message from all partitions
 	if err != nil {
 		return err
 	}
@@ -124,30 +468,56 @@ func (q *queueLogic) setTimerIfNecessary() error {
 }
 
 func (q *queueLogic) setTimer(upcomingTime time.Time) error {
-	nowInSeconds := time.Now().Unix()
+	nowInSeconds := q.clock.Now().Unix()
 	upcomingInSeconds := upcomingTime.Unix()
 	durationInSeconds := (upcomingInSeconds - nowInSeconds) + 2 //add two seconds to be sure that the timer will be executed after the message time
 	duration := time.Second * time.Duration(durationInSeconds)
 
+	q.timerMu.Lock()
+	if q.queueTimer != nil {
+		//keep one timer - it is moved only if the upcoming message is before it
+		if upcomingTime.Before(q.timerAt) {
+			q.logger.Infof("moving timer after - %s", duration)
+			q.queueTimer.Reset(duration)
+			q.timerAt = upcomingTime
+		}
+		q.timerMu.Unlock()
+		return nil
+	}
+
 	q.logger.Infof("setting timer after - %s", duration)
 
-	q.queueTimer = time.NewTimer(duration)
+	timer := q.clock.NewTimer(duration)
+	q.queueTimer = timer
+	q.timerAt = upcomingTime
+	q.timerMu.Unlock()
+
 	select {
-	case <-q.queueTimer.C:
+	case <-timer.C():
 		q.logger.Info("setTimer -> queue timer expired")
-		q.queueTimer = nil
+		q.clearTimer(timer)
 
 		q.processQueue()
 	case <-q.timerDone:
 		// timer aborted
 		q.logger.Info("setTimer -> queue timer aborted")
-		q.queueTimer = nil
+		timer.Stop()
+		q.clearTimer(timer)
 	}
 
 	return nil
 }
 
-func (q *queueLogic) lockQueue() (*bool, *model.Queue, error) {
+func (q *queueLogic) clearTimer(timer Timer) {
+	q.timerMu.Lock()
+	defer q.timerMu.Unlock()
+
+	if q.queueTimer == timer {
+		q.queueTimer = nil
+	}
+}
+
+func (q *queueLogic) lockQueue(queueID string) (*bool, *model.Queue, error) {
 	var err error
 	var queue *model.Queue
 	queueAvailable := true
@@ -155,21 +525,32 @@ func (q *queueLogic) lockQueue() (*bool, *model.Queue, error) {
 	// in transaction
 	transaction := func(context storage.TransactionContext) error {
 		//load queue
-		queue, err = q.storage.LoadQueueWithContext(context)
+		queue, err = q.storage.LoadQueueWithContext(context, queueID)
 		if err != nil {
 			q.logger.Infof("error on loading queue: %s", err)
 			return err
 		}
+		if queue == nil {
+			return fmt.Errorf("there is no queue record %s", queueID)
+		}
 
 		//check if available
-		if queue.Status != "ready" {
-			q.logger.Infof("the queue is not ready but %s", queue.Status)
-			queueAvailable = false
-			return nil
+		now := q.clock.Now().UTC()
+		if queue.Status != model.QueueStatusReady {
+			if !queue.IsLeaseExpired(now) {
+				q.logger.Infof("the queue is not ready but %s", queue.Status)
+				queueAvailable = false
+				return nil
+			}
+			q.logger.Infof("the queue lease of %s has expired, taking over", queue.LeaseOwner)
 		}
 
 		//lock it
-		queue.Status = "processing"
+		leaseExpiresAt := now.Add(queueLeaseDuration)
+		queue.Status = model.QueueStatusProcessing
+		queue.LeaseOwner = q.instanceID
+		queue.LeaseExpiresAt = &leaseExpiresAt
+		queue.LeaseRenewedAt = &now
 		err = q.storage.SaveQueueWithContext(context, *queue)
 		if err != nil {
 			q.logger.Infof("error on marking the queue locked: %s", err)
@@ -188,21 +569,70 @@ func (q *queueLogic) lockQueue() (*bool, *model.Queue, error) {
 	return &queueAvailable, queue, nil
 }
 
+// renewLease extends the queue lease until done is closed. It sets lost if the lease has been taken by another instance.
+func (q *queueLogic) renewLease(queueID string, done chan struct{}, lost *atomic.Bool) {
+	timer := q.clock.NewTimer(queueLeaseHeartbeat)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-timer.C():
+			timer.Reset(queueLeaseHeartbeat)
+
+			renewed, err := q.storage.RenewQueueLease(queueID, q.instanceID, q.clock.Now().UTC().Add(queueLeaseDuration))
+			if err != nil {
+				q.logger.Errorf("error on renewing the queue lease - %s", err)
+				continue //try again on the next heartbeat, the lease is still valid
+			}
+			if !renewed {
+				q.logger.Errorf("the queue lease is not owned by %s anymore", q.instanceID)
+				lost.Store(true)
+				return
+			}
+		}
+	}
+}
+
+// watchLease tries to process the queue again once the lease of the current partition owner expires, so that the partition is taken over if the owner has crashed
+func (q *queueLogic) watchLease(queue model.Queue) {
+	if queue.LeaseExpiresAt == nil {
+		return //nothing to watch
+	}
+	if _, watching := q.leaseWatches.LoadOrStore(queue.ID, true); watching {
+		return //already watching
+	}
+
+	duration := queue.LeaseExpiresAt.Sub(q.clock.Now()) + time.Second
+	go func() {
+		timer := q.clock.NewTimer(duration)
+		select {
+		case <-timer.C():
+			q.leaseWatches.Delete(queue.ID)
+			q.processQueue()
+		case <-q.timerDone:
+			// watch aborted
+			timer.Stop()
+			q.leaseWatches.Delete(queue.ID)
+		}
+	}()
+}
+
 func (q *queueLogic) unlockQueue(queue model.Queue) {
-	queue.Status = "ready"
 	var err error
 	for i := 0; i < 3; i++ {
-		err = q.storage.SaveQueue(queue)
+		err = q.storage.ReleaseQueueLease(queue.ID, q.instanceID)
 		if err == nil {
 			return
 		}
 		q.logger.Errorf("error unlocking the queue (attempt %d) - %s", i+1, err)
-		time.Sleep(200 * time.Millisecond)
+		<-q.clock.NewTimer(queueUnlockRetryDelay).C()
 	}
 	q.logger.Errorf("failed to unlock the queue after retries - %s", err)
 }
 
-func (q *queueLogic) processQueueItem(queueItems []model.QueueItem) error {
+func (q *queueLogic) processQueueItem(queueItems []model.QueueItem, partition int, partitionsCount int) error {
 
 	//get the users as we need their tokens and if they have disabled notifications
 	usersIDs := make([]string, len(queueItems))
@@ -216,7 +646,12 @@ func (q *queueLogic) processQueueItem(queueItems []model.QueueItem) error {
 	}
 
 	//process every item
+	now := q.clock.Now().UTC()
 	itemsIDs := make([]string, len(queueItems))
+	deferred := map[string]time.Time{}            //the items in the users quiet hours or over the delivery rate limit
+	collected := map[string]time.Time{}           //the items collected for the users digests
+	digestItems := map[string][]model.QueueItem{} //org/app/user key -> the due digest items
+	jobItems := []queueJobItem{}
 	for i, item := range queueItems {
 		itemsIDs[i] = item.ID
 
@@ -234,12 +669,109 @@ func (q *queueLogic) processQueueItem(queueItems []model.QueueItem) error {
 			continue //for some reasons there is no a corresponding user
 		}
 
+		if item.IsExpired(now) {
+			q.logger.Infof("queue item (%s) has expired at %s, so drop it", item.ID, item.ExpiresAt)
+			continue //it is too late for sending it
+		}
+
 		if user.NotificationsDisabled {
 			continue //do not send notification if disabled for the user
 		}
 
-		tokens := user.FirebaseTokens
-		go q.sendNotifications(item, tokens) //new thread
+		//defer the item until the end of the user quiet hours unless it is urgent or silent
+		if item.Priority <= q.quietHoursBypassPriority && !item.Silent {
+			quietHoursEnd := user.GetQuietHoursEnd(now)
+			if quietHoursEnd != nil {
+				deferred[item.ID] = *quietHoursEnd
+				continue
+			}
+		}
+
+		//bundle the low priority items in the user digest
+		if user.IsDigestItem(item) {
+			if item.Digest {
+				userKey := q.getUserKey(item.OrgID, item.AppID, item.UserID)
+				digestItems[userKey] = append(digestItems[userKey], item)
+			} else {
+				collected[item.ID] = user.GetDigestWindowEnd(now)
+			}
+			continue
+		}
+
+		tokens := q.getItemTokens(item, user.FirebaseTokens)
+		if len(tokens) == 0 {
+			continue //nothing to send
+		}
+
+		//defer the item if the app has reached its delivery rate
+		deferTo := q.rateLimiter.take(item.OrgID, item.AppID, partition, partitionsCount, now, len(tokens))
+		if deferTo != nil {
+			deferred[item.ID] = *deferTo
+			continue
+		}
+
+		jobItems = append(jobItems, queueJobItem{item: item, tokens: tokens})
+	}
+
+	//send one summary per user for the due digest items
+	if len(digestItems) > 0 {
+		summaries, loadedIDs, err := q.createDigestSummaries(digestItems, now)
+		if err != nil {
+			q.logger.Errorf("error on creating digest summaries - %s", err)
+			return err
+		}
+		itemsIDs = append(itemsIDs, loadedIDs...)
+
+		for _, summary := range summaries {
+			for _, user := range users {
+				if user.OrgID == summary.OrgID && user.AppID == summary.AppID && user.UserID == summary.UserID && len(user.FirebaseTokens) > 0 {
+					jobItems = append(jobItems, queueJobItem{item: summary, tokens: user.FirebaseTokens})
+					break
+				}
+			}
+		}
+	}
+
+	//attach the users unread messages counts as the iOS badges
+	q.setBadges(jobItems)
+
+	//the items with the same payload are sent in batches
+	for _, job := range q.groupJobItems(jobItems) {
+		q.submitJob(job)
+	}
+
+	//keep the deferred items in the queue for later
+	if len(deferred) > 0 {
+		q.logger.Infof("%d items are deferred because of quiet hours or the delivery rate limit", len(deferred))
+
+		err = q.storage.UpdateQueueDataTimes(deferred)
+		if err != nil {
+			q.logger.Errorf("error on deferring queue datas - %s", err)
+			return err
+		}
+	}
+
+	//keep the collected items in the queue until the end of the digest window
+	if len(collected) > 0 {
+		q.logger.Infof("%d items are collected for digests", len(collected))
+
+		err = q.storage.UpdateQueueDataForDigest(collected)
+		if err != nil {
+			q.logger.Errorf("error on collecting queue datas for digests - %s", err)
+			return err
+		}
+	}
+
+	if len(deferred) > 0 || len(collected) > 0 {
+		sentIDs := make([]string, 0, len(itemsIDs))
+		for _, id := range itemsIDs {
+			_, isDeferred := deferred[id]
+			_, isCollected := collected[id]
+			if !isDeferred && !isCollected {
+				sentIDs = append(sentIDs, id)
+			}
+		}
+		itemsIDs = sentIDs
 	}
 
 	//remove the items from the queue
@@ -252,15 +784,461 @@ func (q *queueLogic) processQueueItem(queueItems []model.QueueItem) error {
 	return nil
 }
 
-func (q *queueLogic) sendNotifications(queueItem model.QueueItem, tokens []model.FirebaseToken) {
+// setBadges sets the users current unread messages counts to the job items. The counts are loaded in one aggregation for the whole batch
+// instead of one per send. The items are sent without a badge if the counts cannot be loaded.
+func (q *queueLogic) setBadges(jobItems []queueJobItem) {
+	if len(jobItems) == 0 {
+		return
+	}
+
+	usersIDs := []string{}
+	added := map[string]bool{}
+	for _, jobItem := range jobItems {
+		if !added[jobItem.item.UserID] {
+			added[jobItem.item.UserID] = true
+			usersIDs = append(usersIDs, jobItem.item.UserID)
+		}
+	}
+	unreadCounts, err := q.storage.FindUsersUnreadCounts(usersIDs)
+	if err != nil {
+		q.logger.Errorf("error on counting the users unread messages - %s", err)
+		return
+	}
+
+	counts := make(map[string]int, len(unreadCounts))
+	for _, unreadCount := range unreadCounts {
+		counts[q.getUserKey(unreadCount.OrgID, unreadCount.AppID, unreadCount.UserID)] = unreadCount.Count
+	}
+	for i, jobItem := range jobItems {
+		badge := counts[q.getUserKey(jobItem.item.OrgID, jobItem.item.AppID, jobItem.item.UserID)] //0 if the user has no unread messages
+		jobItems[i].badge = &badge
+	}
+}
+
+func (q *queueLogic) getUserKey(orgID string, appID string, userID string) string {
+	return fmt.Sprintf("%s_%s_%s", orgID, appID, userID)
+}
+
+// sendBadgeUpdate sends a silent push with the user current unread messages count to the user devices so that their badges are updated
+func (q *queueLogic) sendBadgeUpdate(orgID string, appID string, userID string) {
+	user, err := q.storage.FindUserByID(orgID, appID, userID)
+	if err != nil {
+		q.logger.Errorf("error on finding user %s for a badge update - %s", userID, err)
+		return
+	}
+	if user == nil || user.NotificationsDisabled || len(user.FirebaseTokens) == 0 {
+		return //nothing to update
+	}
+
+	unreadCounts, err := q.storage.FindUsersUnreadCounts([]string{userID})
+	if err != nil {
+		q.logger.Errorf("error on counting the user %s unread messages - %s", userID, err)
+		return
+	}
+	badge := 0
+	for _, unreadCount := range unreadCounts {
+		if unreadCount.OrgID == orgID && unreadCount.AppID == appID {
+			badge = unreadCount.Count
+			break
+		}
+	}
+
+	messages := make([]model.FirebaseMessage, len(user.FirebaseTokens))
+	for i, fToken := range user.FirebaseTokens {
+		messages[i] = model.FirebaseMessage{Token: fToken.Token, Data: map[string]string{"badge": strconv.Itoa(badge)},
+			Silent: true, Badge: &badge}
+	}
+	for start := 0; start < len(messages); start += model.FirebaseMaxBatchSize {
+		batch := messages[start:min(start+model.FirebaseMaxBatchSize, len(messages))]
+		results, err := q.firebase.SendNotifications(orgID, appID, batch)
+		if err != nil {
+			q.logger.Errorf("error on sending badge update to user %s - %s", userID, err)
+			continue
+		}
+		for _, result := range results {
+			if result.Err != nil {
+				q.logger.Warnf("error on sending badge update to token %s - %s", result.Token, result.Err)
+			}
+		}
+	}
+}
+
+// createDigestSummaries creates one summary item per user for all the user due digest items. It gives the ids of the due digest items
+// which have been loaded in addition to the given ones as they have to be removed as well.
+func (q *queueLogic) createDigestSummaries(digestItems map[string][]model.QueueItem, now time.Time) ([]model.QueueItem, []string, error) {
+	//load the rest of the users due digest items, they may not be in the current batch. The user ids are unique only within an org/app.
+	type orgAppUsers struct {
+		orgID    string
+		appID    string
+		usersIDs []string
+	}
+	orgsApps := map[string]*orgAppUsers{}
+	givenIDs := map[string]bool{}
+	for _, items := range digestItems {
+		first := items[0]
+		orgAppKey := fmt.Sprintf("%s_%s", first.OrgID, first.AppID)
+		if orgsApps[orgAppKey] == nil {
+			orgsApps[orgAppKey] = &orgAppUsers{orgID: first.OrgID, appID: first.AppID}
+		}
+		orgsApps[orgAppKey].usersIDs = append(orgsApps[orgAppKey].usersIDs, first.UserID)
+		for _, item := range items {
+			givenIDs[item.ID] = true
+		}
+	}
+	loadedIDs := []string{}
+	for _, orgApp := range orgsApps {
+		dueItems, err := q.storage.FindQueueDataForDigest(orgApp.orgID, orgApp.appID, orgApp.usersIDs, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, item := range dueItems {
+			if !givenIDs[item.ID] {
+				loadedIDs = append(loadedIDs, item.ID)
+				userKey := q.getUserKey(item.OrgID, item.AppID, item.UserID)
+				digestItems[userKey] = append(digestItems[userKey], item)
+			}
+		}
+	}
+
+	//create the summaries
+	summaries := make([]model.QueueItem, 0, len(digestItems))
+	for _, items := range digestItems {
+		messagesIDs := make([]string, len(items))
+		topic := items[0].Topic
+		for i, item := range items {
+			messagesIDs[i] = item.MessageID
+			if topic != nil && (item.Topic == nil || *item.Topic != *topic) {
+				topic = nil //from different topics
+			}
+		}
+
+		subject := fmt.Sprintf("%d new updates", len(items))
+		if len(items) == 1 {
+			subject = "1 new update"
+		}
+		if topic != nil {
+			subject = fmt.Sprintf("%s from %s", subject, *topic)
+		}
+		data := map[string]string{"type": "digest", "count": strconv.Itoa(len(items)), "messages_ids": strings.Join(messagesIDs, ",")}
+
+		summary := model.QueueItem{OrgID: items[0].OrgID, AppID: items[0].AppID, ID: uuid.NewString(), UserID: items[0].UserID,
+			Subject: subject, Body: "Open the notifications inbox to see them", Data: data, Time: now,
+			Priority: model.MessagePriorityDefault, Topic: topic, DigestOf: messagesIDs}
+		summaries = append(summaries, summary)
+	}
+	return summaries, loadedIDs, nil
+}
+
+func (q *queueLogic) getItemTokens(queueItem model.QueueItem, userTokens []model.FirebaseToken) []model.FirebaseToken {
+	if len(queueItem.Tokens) == 0 {
+		return userTokens //first attempt - send to all user tokens
+	}
+
+	//retry - send only to the tokens which have failed and the user still has
+	tokens := []model.FirebaseToken{}
+	for _, userToken := range userTokens {
+		for _, token := range queueItem.Tokens {
+			if userToken.Token == token {
+				tokens = append(tokens, userToken)
+				break
+			}
+		}
+	}
+	return tokens
+}
+
+// groupJobItems groups the items with the same payload in jobs with up to model.FirebaseMaxBatchSize tokens
+func (q *queueLogic) groupJobItems(jobItems []queueJobItem) []queueJob {
+	jobs := []queueJob{}
+	openJobs := map[string]int{}    //payload key -> index of the job which is being filled
+	tokensCount := map[string]int{} //payload key -> tokens count in the job which is being filled
+	for _, jobItem := range jobItems {
+		key := q.getPayloadKey(jobItem.item)
+		index, exists := openJobs[key]
+		if !exists || tokensCount[key]+len(jobItem.tokens) > model.FirebaseMaxBatchSize {
+			jobs = append(jobs, queueJob{})
+			index = len(jobs) - 1
+			openJobs[key] = index
+			tokensCount[key] = 0
+		}
+		jobs[index].items = append(jobs[index].items, jobItem)
+		tokensCount[key] += len(jobItem.tokens)
+	}
+	return jobs
+}
+
+func (q *queueLogic) getPayloadKey(item model.QueueItem) string {
+	data, _ := json.Marshal(item.Data) //the map keys are sorted
+	return fmt.Sprintf("%s_%s_%s_%s_%s_%s", item.OrgID, item.AppID, item.MessageID, item.Subject, item.Body, data)
+}
+
+func (q *queueLogic) sendNotifications(job queueJob) {
+	if len(job.items) == 0 {
+		return
+	}
+	orgID := job.items[0].item.OrgID
+	appID := job.items[0].item.AppID
+
+	//prepare the messages
+	messages := []model.FirebaseMessage{}
+	for _, jobItem := range job.items {
+		for _, fToken := range jobItem.tokens {
+			title, body := jobItem.item.GetLocalizedContent(fToken.Locale) //in the device language
+			messages = append(messages, model.FirebaseMessage{Token: fToken.Token,
+				Title: title, Body: body, Data: jobItem.item.Data, Priority: jobItem.item.Priority,
+				ExpiresAt: jobItem.item.ExpiresAt, CollapseKey: jobItem.item.CollapseKey, PushOptions: jobItem.item.PushOptions,
+				Silent: jobItem.item.Silent, Badge: jobItem.badge})
+		}
+	}
 
-	for _, fToken := range tokens {
+	//send them in batches
+	results := make([]model.FirebaseSendResult, 0, len(messages))
+	for start := 0; start < len(messages); start += model.FirebaseMaxBatchSize {
+		batch := messages[start:min(start+model.FirebaseMaxBatchSize, len(messages))]
+		batchResults, err := q.firebase.SendNotifications(orgID, appID, batch)
+		if err != nil {
+			//the whole batch has failed
+			q.logger.Errorf("error on sending notifications batch - %s", err)
+			batchResults = make([]model.FirebaseSendResult, len(batch))
+			for i, message := range batch {
+				batchResults[i] = model.FirebaseSendResult{Token: message.Token, Err: err}
+			}
+		}
+		results = append(results, batchResults...)
+	}
+
+	//handle the results for every item
+	attempts := []model.DeliveryAttempt{}
+	index := 0
+	for _, jobItem := range job.items {
+		count := len(jobItem.tokens)
+		itemAttempts := q.handleSendResults(jobItem.item, jobItem.tokens, results[index:index+count])
+		attempts = append(attempts, itemAttempts...)
+		index += count
+	}
+
+	//store the attempts
+	if len(attempts) > 0 {
+		err := q.storage.InsertDeliveryAttempts(attempts)
+		if err != nil {
+			q.logger.Errorf("error on storing delivery attempts - %s", err)
+		}
+	}
+}
+
+// handleSendResults retries, prunes or moves to the dead letters the failed tokens. It gives the delivery attempts.
+func (q *queueLogic) handleSendResults(queueItem model.QueueItem, tokens []model.FirebaseToken, results []model.FirebaseSendResult) []model.DeliveryAttempt {
+	attempts := make([]model.DeliveryAttempt, len(tokens))
+	retryTokens := []string{}
+	failedTokens := []string{}
+	var lastErr error
+	for i, fToken := range tokens {
 		token := fToken.Token
-		sendErr := q.firebase.SendNotificationToToken(queueItem.OrgID, queueItem.AppID, token, queueItem.Subject, queueItem.Body, queueItem.Data)
+		sendErr := results[i].Err
 		if sendErr != nil {
 			q.logger.Errorf("error send notification to token (%s): %s", token, sendErr)
+			lastErr = sendErr
+
+			var fcmErr *model.FirebaseSendError
+			isFCMErr := errors.As(sendErr, &fcmErr)
+			if isFCMErr && fcmErr.IsInvalidToken() {
+				q.pruneToken(queueItem, fToken, fcmErr.Code) //no need to keep it in the dead letters as it cannot be delivered anymore
+			} else if isFCMErr && fcmErr.IsTransient() {
+				retryTokens = append(retryTokens, token)
+			} else {
+				failedTokens = append(failedTokens, token)
+			}
 		} else {
 			q.logger.Infof("queue item(%s:%s:%s) has been sent to token: %s", queueItem.ID, queueItem.Subject, queueItem.Body, token)
 		}
+
+		attempts[i] = q.createDeliveryAttempt(queueItem, fToken, results[i].MessageID, sendErr)
+	}
+
+	//put back in the queue the tokens which have failed because of a transient error
+	if len(retryTokens) > 0 {
+		scheduled := q.scheduleRetry(queueItem, retryTokens)
+		if !scheduled {
+			failedTokens = append(failedTokens, retryTokens...)
+		}
+	}
+
+	//move to the dead letters the tokens which cannot be delivered
+	if len(failedTokens) > 0 {
+		q.moveToDeadLetters(queueItem, failedTokens, lastErr)
+	}
+
+	return attempts
+}
+
+func (q *queueLogic) pruneToken(queueItem model.QueueItem, fToken model.FirebaseToken, errorCode string) {
+	q.logger.Infof("pruning invalid token (%s) for user (%s) - %s", fToken.Token, queueItem.UserID, errorCode)
+
+	//get the user topics before removing the token
+	user, err := q.storage.FindUserByID(queueItem.OrgID, queueItem.AppID, queueItem.UserID)
+	if err != nil {
+		q.logger.Errorf("error on finding user (%s) for pruning token - %s", queueItem.UserID, err)
+		return
+	}
+
+	//remove the token from the user
+	err = q.storage.RemoveFirebaseTokenFromUser(queueItem.OrgID, queueItem.AppID, queueItem.UserID, fToken.Token)
+	if err != nil {
+		q.logger.Errorf("error on removing token (%s) from user (%s) - %s", fToken.Token, queueItem.UserID, err)
+		return
+	}
+
+	//drop the token topic subscriptions
+	topics := []string{}
+	if user != nil {
+		for _, topic := range user.Topics {
+			err = q.firebase.UnsubscribeToTopic(queueItem.OrgID, queueItem.AppID, fToken.Token, topic)
+			if err != nil {
+				q.logger.Errorf("error on unsubscribing token (%s) from topic (%s) - %s", fToken.Token, topic, err)
+				continue
+			}
+			topics = append(topics, topic)
+		}
+	}
+
+	//record it
+	prune := model.TokenPrune{OrgID: queueItem.OrgID, AppID: queueItem.AppID, ID: uuid.NewString(),
+		UserID: queueItem.UserID, Token: fToken.Token, AppPlatform: fToken.AppPlatform, Topics: topics,
+		QueueItemID: queueItem.ID, MessageID: queueItem.MessageID, ErrorCode: errorCode, DateCreated: q.clock.Now().UTC()}
+	err = q.storage.InsertTokenPrune(prune)
+	if err != nil {
+		q.logger.Errorf("error on recording token prune for token (%s) - %s", fToken.Token, err)
+	}
+}
+
+func (q *queueLogic) scheduleRetry(queueItem model.QueueItem, tokens []string) bool {
+	attempt := queueItem.Attempts + 1
+	if attempt >= maxSendAttempts {
+		q.logger.Errorf("queue item (%s) failed for %d tokens after %d attempts, giving up", queueItem.ID, len(tokens), attempt)
+		return false
+	}
+
+	retryItem := queueItem
+	retryItem.ID = uuid.NewString() //the current item is removed from the queue once processed
+	retryItem.Attempts = attempt
+	retryItem.Tokens = tokens
+	retryItem.Time = q.clock.Now().Add(q.getRetryDelay(attempt))
+
+	err := q.storage.InsertQueueDataItemsWithContext(context.Background(), []model.QueueItem{retryItem})
+	if err != nil {
+		q.logger.Errorf("error on scheduling retry for queue item (%s) - %s", queueItem.ID, err)
+		return false
+	}
+	q.logger.Infof("queue item (%s) scheduled for retry %d at %s as %s", queueItem.ID, attempt, retryItem.Time, retryItem.ID)
+
+	//let the queue know so that the timer is set
+	go q.onQueuePush() //do not block the worker
+	return true
+}
+
+func (q *queueLogic) moveToDeadLetters(queueItem model.QueueItem, tokens []string, lastErr error) {
+	deadLetter := model.QueueDeadLetter{OrgID: queueItem.OrgID, AppID: queueItem.AppID, ID: uuid.NewString(),
+		QueueItemID: queueItem.ID, MessageID: queueItem.MessageID, MessageRecipientID: queueItem.MessageRecipientID, UserID: queueItem.UserID,
+		Subject: queueItem.Subject, Body: queueItem.Body, Data: queueItem.Data, Localizations: queueItem.Localizations, Priority: queueItem.Priority,
+		ExpiresAt: queueItem.ExpiresAt, CollapseKey: queueItem.CollapseKey, Topic: queueItem.Topic, DigestOf: queueItem.DigestOf,
+		PushOptions: queueItem.PushOptions, Silent: queueItem.Silent, Tokens: tokens, Attempts: queueItem.Attempts + 1, DateCreated: q.clock.Now().UTC()}
+	if lastErr != nil {
+		deadLetter.Error = lastErr.Error()
+
+		var fcmErr *model.FirebaseSendError
+		if errors.As(lastErr, &fcmErr) && len(fcmErr.Code) > 0 {
+			errorCode := fcmErr.Code
+			deadLetter.ErrorCode = &errorCode
+		}
+	}
+
+	err := q.storage.InsertQueueDeadLetters([]model.QueueDeadLetter{deadLetter})
+	if err != nil {
+		q.logger.Errorf("error on moving queue item (%s) to the dead letters - %s", queueItem.ID, err)
+		return
+	}
+	q.logger.Infof("queue item (%s) moved to the dead letters as %s", queueItem.ID, deadLetter.ID)
+}
+
+// getRetryDelay gives the delay for the passed retry attempt (1 based) - 30s, 1m, 2m, 4m.. up to retryMaxDelay
+func (q *queueLogic) getRetryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+func (q *queueLogic) createDeliveryAttempt(queueItem model.QueueItem, fToken model.FirebaseToken, fcmMessageID string, sendErr error) model.DeliveryAttempt {
+	attempt := model.DeliveryAttempt{OrgID: queueItem.OrgID, AppID: queueItem.AppID, ID: uuid.NewString(),
+		QueueItemID: queueItem.ID, MessageID: queueItem.MessageID, MessageRecipientID: queueItem.MessageRecipientID,
+		UserID: queueItem.UserID, Token: fToken.Token, AppPlatform: fToken.AppPlatform, DateCreated: q.clock.Now().UTC()}
+
+	if sendErr != nil {
+		attempt.Status = model.DeliveryStatusFailed
+		errMessage := sendErr.Error()
+		attempt.Error = &errMessage
+
+		var fcmErr *model.FirebaseSendError
+		if errors.As(sendErr, &fcmErr) && len(fcmErr.Code) > 0 {
+			errorCode := fcmErr.Code
+			attempt.ErrorCode = &errorCode
+		}
+	} else {
+		attempt.Status = model.DeliveryStatusSent
+		attempt.FCMMessageID = &fcmMessageID
+	}
+	return attempt
+}
+
+func newQueueLogic(logger *logs.Logger, storage Storage, firebase Firebase, clock Clock, timerDone chan bool, config model.QueueConfig) *queueLogic {
+	workersCount := config.WorkersCount
+	if workersCount <= 0 {
+		workersCount = defaultQueueWorkersCount
+	}
+	bufferSize := config.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultQueueBufferSize
+	}
+	backpressureThreshold := config.BackpressureThreshold
+	if backpressureThreshold <= 0 || backpressureThreshold > bufferSize {
+		backpressureThreshold = bufferSize / 2
+	}
+	if backpressureThreshold <= 0 {
+		backpressureThreshold = 1
+	}
+
+	partitionsCount := config.PartitionsCount
+	if partitionsCount <= 0 {
+		partitionsCount = defaultPartitionsCount
+	}
+
+	//identify the instance as the queue lease owner
+	hostname, _ := os.Hostname()
+	instanceID := fmt.Sprintf("%s_%s", hostname, uuid.NewString())
+
+	return &queueLogic{logger: logger, instanceID: instanceID, partitionsCount: partitionsCount, rateLimiter: newRateLimiter(),
+		quietHoursBypassPriority: config.QuietHoursBypassPriority,
+		storage:                  storage, firebase: firebase, clock: clock, timerDone: timerDone,
+		workersCount: workersCount, backpressureThreshold: backpressureThreshold, jobs: make(chan queueJob, bufferSize),
+		inFlightCond: sync.NewCond(&sync.Mutex{})}
+}
+
+// waitWithContext waits for the wait group until the context is done. It gives false if the context is done first.
+func waitWithContext(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
 	}
 }
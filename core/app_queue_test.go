@@ -0,0 +1,341 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"notifications/core/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+
+func TestQueueSendsDueItemsInPriorityOrder(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.addUser("u1")
+	ta.storage.addQueueItems(
+		newTestQueueItem("low", "u1", testNow.Add(-time.Hour), 1),
+		newTestQueueItem("high", "u1", testNow.Add(-time.Minute), 10),
+		newTestQueueItem("medium", "u1", testNow.Add(-30*time.Minute), 5),
+		newTestQueueItem("upcoming", "u1", testNow.Add(time.Hour), 10),
+	)
+
+	ta.startQueue(t)
+
+	waitFor(t, "the due items to be sent", func() bool { return len(ta.firebase.sentTitles()) == 3 })
+	expected := []string{"high", "medium", "low"}
+	if sent := ta.firebase.sentTitles(); !reflect.DeepEqual(sent, expected) {
+		t.Errorf("sent %v, expected %v", sent, expected)
+	}
+	if _, exists := ta.storage.getQueueItem("upcoming"); !exists {
+		t.Error("the upcoming item must stay in the queue")
+	}
+}
+
+func TestQueueGivesFairShareToOldestItems(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	for _, id := range []string{"urgent1", "urgent2", "urgent3", "urgent4", "urgent5"} {
+		ta.storage.addQueueItems(newTestQueueItem(id, "u1", testNow.Add(-time.Minute), 10))
+	}
+	ta.storage.addQueueItems(newTestQueueItem("oldest", "u1", testNow.Add(-time.Hour), 1))
+
+	items, err := ta.app.queueLogic.findDueItems(testNow, 0, 1, 5)
+	if err != nil {
+		t.Fatalf("error on finding the due items - %s", err)
+	}
+
+	if len(items) != 5 {
+		t.Fatalf("found %d items, expected 5", len(items))
+	}
+	if last := items[len(items)-1]; last.ID != "oldest" {
+		t.Errorf("the last item is %s, expected the oldest one", last.ID)
+	}
+}
+
+func TestQueueTimerProcessesUpcomingItem(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.addUser("u1")
+	ta.storage.addQueueItems(newTestQueueItem("upcoming", "u1", testNow.Add(time.Hour), 5))
+
+	ta.startQueue(t)
+
+	//two seconds are added to be sure that the item is due
+	expiresAt := testNow.Add(time.Hour + 2*time.Second)
+	waitFor(t, "the queue timer", func() bool { return reflect.DeepEqual(ta.clock.ActiveTimers(), []time.Time{expiresAt}) })
+	if sent := ta.firebase.sentTitles(); len(sent) != 0 {
+		t.Fatalf("sent %v before the item time", sent)
+	}
+
+	ta.clock.Advance(time.Hour + 2*time.Second)
+
+	waitFor(t, "the upcoming item to be sent", func() bool { return len(ta.firebase.sentTitles()) == 1 })
+	if _, exists := ta.storage.getQueueItem("upcoming"); exists {
+		t.Error("the sent item must be removed from the queue")
+	}
+}
+
+func TestQueueTimerIsMovedToEarlierItem(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.addUser("u1")
+	ta.startQueue(t)
+	q := ta.app.queueLogic
+
+	go q.setTimer(testNow.Add(10 * time.Minute))
+	waitFor(t, "the queue timer", func() bool { return len(ta.clock.ActiveTimers()) == 1 })
+
+	//an earlier item moves the timer
+	q.setTimer(testNow.Add(5 * time.Minute))
+	expected := []time.Time{testNow.Add(5*time.Minute + 2*time.Second)}
+	if timers := ta.clock.ActiveTimers(); !reflect.DeepEqual(timers, expected) {
+		t.Fatalf("active timers %v, expected %v", timers, expected)
+	}
+
+	//a later item does not
+	q.setTimer(testNow.Add(20 * time.Minute))
+	if timers := ta.clock.ActiveTimers(); !reflect.DeepEqual(timers, expected) {
+		t.Fatalf("active timers %v, expected %v", timers, expected)
+	}
+
+	ta.storage.addQueueItems(newTestQueueItem("soon", "u1", testNow.Add(5*time.Minute), 5))
+	ta.clock.Advance(5*time.Minute + 2*time.Second)
+
+	waitFor(t, "the item to be sent", func() bool { return len(ta.firebase.sentTitles()) == 1 })
+}
+
+func TestQueueDataInsertsAreDebounced(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.addUser("u1")
+	ta.startQueue(t)
+	q := ta.app.queueLogic
+	runs := ta.storage.getFindQueuesCount()
+
+	for i := 0; i < 100; i++ {
+		q.onQueueDataInserted()
+	}
+	if timers := ta.clock.ActiveTimers(); len(timers) != 1 {
+		t.Fatalf("%d active timers, expected one for all inserts", len(timers))
+	}
+
+	ta.storage.addQueueItems(newTestQueueItem("inserted", "u1", testNow, 5))
+	ta.clock.Advance(queueInsertsDebounce)
+
+	waitFor(t, "the inserted item to be sent", func() bool { return len(ta.firebase.sentTitles()) == 1 })
+	if count := ta.storage.getFindQueuesCount() - runs; count != 1 {
+		t.Errorf("the queue has been processed %d times, expected once", count)
+	}
+
+	//the next inserts are processed again
+	waitFor(t, "the next inserts to be debounced", func() bool {
+		q.onQueueDataInserted()
+		return len(ta.clock.ActiveTimers()) == 1
+	})
+}
+
+func TestQueueKeepsPausedAppItems(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.addUser("u1")
+	pausedItem := newTestQueueItem("paused", "u1", testNow.Add(-time.Minute), 5)
+	pausedItem.AppID = "paused_app"
+	ta.storage.addQueueItems(pausedItem, newTestQueueItem("active", "u1", testNow.Add(-time.Minute), 5))
+	ta.storage.pauses = []model.QueuePause{{OrgID: "org", AppID: "paused_app", ID: "pause"}}
+
+	ta.startQueue(t)
+
+	waitFor(t, "the active item to be sent", func() bool { return len(ta.firebase.sentTitles()) == 1 })
+	if sent := ta.firebase.sentTitles(); sent[0] != "active" {
+		t.Errorf("sent %v, expected the active item", sent)
+	}
+	if _, exists := ta.storage.getQueueItem("paused"); !exists {
+		t.Error("the paused app item must stay in the queue")
+	}
+
+	//the due paused item must not keep the timer firing
+	q := ta.app.queueLogic
+	q.timerMu.Lock()
+	defer q.timerMu.Unlock()
+	if q.queueTimer != nil {
+		t.Error("the timer must not be set for the paused app items")
+	}
+}
+
+func TestQueueSetsUnreadCountAsBadge(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.addUser("u1")
+	ta.addUser("u2")
+	ta.storage.unreadCounts = []model.UserUnreadCount{{OrgID: "org", AppID: "app", UserID: "u1", Count: 3}}
+	ta.storage.addQueueItems(newTestQueueItem("first", "u1", testNow.Add(-time.Minute), 5),
+		newTestQueueItem("second", "u2", testNow.Add(-time.Minute), 5))
+
+	ta.startQueue(t)
+
+	waitFor(t, "the items to be sent", func() bool { return len(ta.firebase.sentTitles()) == 2 })
+	badges := map[string]int{}
+	for _, message := range ta.firebase.sentMessages() {
+		if message.Badge == nil {
+			t.Fatalf("no badge for %s", message.Token)
+		}
+		badges[message.Token] = *message.Badge
+	}
+	expected := map[string]int{"token_u1": 3, "token_u2": 0}
+	if !reflect.DeepEqual(badges, expected) {
+		t.Errorf("badges %v, expected %v", badges, expected)
+	}
+}
+
+func TestBadgeUpdateIsSilent(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.addUser("u1")
+	ta.storage.unreadCounts = []model.UserUnreadCount{{OrgID: "org", AppID: "app", UserID: "u1", Count: 2}}
+
+	ta.app.queueLogic.sendBadgeUpdate("org", "app", "u1")
+
+	sent := ta.firebase.sentMessages()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, expected one", len(sent))
+	}
+	if !sent[0].Silent || sent[0].Badge == nil || *sent[0].Badge != 2 || len(sent[0].Title) > 0 {
+		t.Errorf("sent %+v, expected a silent badge update", sent[0])
+	}
+}
+
+func TestQueueSendsContentInTokenLocale(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	es, ptBR, fr := "es_MX", "pt-BR", "fr"
+	ta.storage.users = append(ta.storage.users, model.User{OrgID: "org", AppID: "app", ID: "u1", UserID: "u1",
+		FirebaseTokens: []model.FirebaseToken{{Token: "token_es", Locale: &es}, {Token: "token_pt", Locale: &ptBR},
+			{Token: "token_fr", Locale: &fr}, {Token: "token_none"}}})
+	item := newTestQueueItem("first", "u1", testNow.Add(-time.Minute), 5)
+	item.Localizations = map[string]model.MessageLocalization{"es": {Subject: "hola", Body: "cuerpo"}, "pt-PT": {Subject: "olá", Body: "corpo"}}
+	ta.storage.addQueueItems(item)
+
+	ta.startQueue(t)
+
+	waitFor(t, "the item to be sent", func() bool { return len(ta.firebase.sentTitles()) == 4 })
+	titles := map[string]string{}
+	for _, message := range ta.firebase.sentMessages() {
+		titles[message.Token] = message.Title + "/" + message.Body
+	}
+	expected := map[string]string{"token_es": "hola/cuerpo", "token_pt": "olá/corpo", "token_fr": "first/body", "token_none": "first/body"}
+	if !reflect.DeepEqual(titles, expected) {
+		t.Errorf("sent %v, expected %v", titles, expected)
+	}
+}
+
+func TestQueueDigestIncludesOnlyTheUserAppItems(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.storage.users = append(ta.storage.users, model.User{OrgID: "org", AppID: "app", ID: "u1", UserID: "u1",
+		FirebaseTokens: []model.FirebaseToken{{Token: "token_u1"}}, Digest: &model.DigestSettings{Interval: 60}})
+	first := newTestQueueItem("first", "u1", testNow.Add(-time.Minute), model.MessagePriorityDefault)
+	first.Digest = true
+	second := newTestQueueItem("second", "u1", testNow.Add(-time.Minute), model.MessagePriorityDefault)
+	second.Digest = true
+	//the same user id in another app, it is paused so that it is not in the processed batch
+	other := newTestQueueItem("other", "u1", testNow.Add(-time.Minute), model.MessagePriorityDefault)
+	other.AppID, other.Digest = "other_app", true
+	ta.storage.pauses = []model.QueuePause{{OrgID: "org", AppID: "other_app"}}
+	ta.storage.addQueueItems(first, second, other)
+
+	ta.startQueue(t)
+
+	waitFor(t, "the digest to be sent", func() bool { return len(ta.firebase.sentTitles()) == 1 })
+	if titles := ta.firebase.sentTitles(); titles[0] != "2 new updates" {
+		t.Errorf("sent %v, expected the digest of the two app items", titles)
+	}
+	waitFor(t, "the digest items to be removed", func() bool {
+		_, firstExists := ta.storage.getQueueItem("first")
+		_, secondExists := ta.storage.getQueueItem("second")
+		return !firstExists && !secondExists
+	})
+	if _, exists := ta.storage.getQueueItem("other"); !exists {
+		t.Errorf("the other app item has been removed")
+	}
+}
+
+func TestQueueUnlockRetriesOnTheClock(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.storage.releaseLeaseErrors = 2
+
+	done := make(chan struct{})
+	go func() {
+		ta.app.queueLogic.unlockQueue(model.Queue{ID: "0"})
+		close(done)
+	}()
+
+	//every retry waits for the clock
+	for i := 0; i < ta.storage.releaseLeaseErrors; i++ {
+		waitFor(t, "the unlock retry timer", func() bool { return len(ta.clock.ActiveTimers()) == 1 })
+		ta.clock.Advance(queueUnlockRetryDelay)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout on waiting for the queue to be unlocked")
+	}
+	if count := ta.storage.releaseLeaseCount; count != 3 {
+		t.Errorf("released the lease %d times, expected 3", count)
+	}
+}
+
+func TestQueueSendsThePastDueItemsLeftByAStoppedInstance(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.addUser("u1")
+	ta.storage.addQueueItems(newTestQueueItem("left", "u1", testNow.Add(time.Minute), model.MessagePriorityDefault))
+
+	ta.app.queueLogic.start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := ta.app.queueLogic.stop(ctx)
+	if err != nil {
+		t.Fatalf("error on stopping the queue - %s", err)
+	}
+
+	//the replacement instance starts once the item has become due
+	restarted := newTestAppWithStorage(t, testNow.Add(time.Hour), ta.storage)
+	restarted.startQueue(t)
+
+	waitFor(t, "the left item to be sent", func() bool { return len(restarted.firebase.sentTitles()) == 1 })
+	if sent := restarted.firebase.sentTitles(); sent[0] != "left" {
+		t.Errorf("sent %v, expected the left item", sent)
+	}
+}
+
+func TestQueuePrunesOnlyTheNotRegisteredTokens(t *testing.T) {
+	ta := newTestApp(t, testNow)
+	ta.storage.users = append(ta.storage.users, model.User{OrgID: "org", AppID: "app", ID: "u1", UserID: "u1",
+		FirebaseTokens: []model.FirebaseToken{{Token: "unregistered"}, {Token: "bad_payload"}}})
+	item := newTestQueueItem("item", "u1", testNow, model.MessagePriorityDefault)
+	tokens := []model.FirebaseToken{{Token: "unregistered"}, {Token: "bad_payload"}}
+	results := []model.FirebaseSendResult{
+		{Token: "unregistered", Err: &model.FirebaseSendError{Code: model.FirebaseErrorTokenNotRegistered, Token: "unregistered", Err: errors.New("not registered")}},
+		//FCM gives invalid argument for a bad payload too, so it does not say anything about the token
+		{Token: "bad_payload", Err: &model.FirebaseSendError{Code: model.FirebaseErrorInvalidArgument, Token: "bad_payload", Err: errors.New("invalid argument")}},
+	}
+
+	ta.app.queueLogic.handleSendResults(item, tokens, results)
+
+	if len(ta.storage.prunes) != 1 || ta.storage.prunes[0].Token != "unregistered" {
+		t.Errorf("pruned %v, expected only the not registered token", ta.storage.prunes)
+	}
+	if userTokens := ta.storage.users[0].FirebaseTokens; len(userTokens) != 1 || userTokens[0].Token != "bad_payload" {
+		t.Errorf("the user has tokens %v, expected the invalid argument one to be kept", userTokens)
+	}
+	if len(ta.storage.deadLetter) != 1 || !containsString(ta.storage.deadLetter[0].Tokens, "bad_payload") {
+		t.Errorf("dead letters %v, expected the invalid argument token", ta.storage.deadLetter)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"notifications/core/model"
+	"sync"
+	"time"
+)
+
+// rateBucket is a token bucket which limits how many messages are sent per second
+type rateBucket struct {
+	limitKey string
+
+	rate    float64 //tokens per second
+	burst   float64
+	tokens  float64
+	updated time.Time
+
+	deferredUntil time.Time //the budget up to this moment has been promised to the deferred items
+}
+
+// take takes count tokens from the bucket. It gives nil if they are available, otherwise the time when the item should be tried again.
+func (b *rateBucket) take(now time.Time, count int) *time.Time {
+	//refill
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed*b.rate)
+		b.updated = now
+	}
+
+	cost := min(float64(count), b.burst) //a bigger item would never fit in the bucket
+	if b.tokens >= cost {
+		b.tokens -= cost
+		return nil
+	}
+
+	//defer the item after the ones which have already been deferred
+	start := now
+	need := cost - b.tokens
+	if b.deferredUntil.After(now) {
+		start = b.deferredUntil
+		need = cost
+	}
+	b.deferredUntil = start.Add(time.Duration(need / b.rate * float64(time.Second)))
+
+	//a copy as the bucket is changed by the next takes once the limiter is unlocked
+	deferredUntil := b.deferredUntil
+	return &deferredUntil
+}
+
+// rateLimiter limits the delivery rate per org/app. Every partition gets equal part of the limit as the users are spread evenly among the partitions.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]model.FirebaseRateLimit //org_app -> limit
+	buckets map[string]*rateBucket             //org_app_partition_partitionsCount -> bucket
+}
+
+// setLimits sets the configured limits. The buckets of the changed limits are reset.
+func (r *rateLimiter) setLimits(firebaseConfs []model.FirebaseConf) {
+	limits := map[string]model.FirebaseRateLimit{}
+	for _, conf := range firebaseConfs {
+		if conf.RateLimit != nil && conf.RateLimit.Rate > 0 {
+			limits[r.getLimitKey(conf.OrgID, conf.AppID)] = *conf.RateLimit
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, limit := range r.limits {
+		if newLimit, ok := limits[key]; !ok || newLimit != limit {
+			r.removeBuckets(key)
+		}
+	}
+	r.limits = limits
+}
+
+// take takes count tokens for the org/app in the partition. It gives nil if the item can be sent now, otherwise the time when it should be tried again.
+func (r *rateLimiter) take(orgID string, appID string, partition int, partitionsCount int, now time.Time, count int) *time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limitKey := r.getLimitKey(orgID, appID)
+	limit, ok := r.limits[limitKey]
+	if !ok {
+		return nil //not limited
+	}
+
+	bucketKey := fmt.Sprintf("%s_%d_%d", limitKey, partition, partitionsCount)
+	bucket := r.buckets[bucketKey]
+	if bucket == nil {
+		share := float64(max(partitionsCount, 1))
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = limit.Rate
+		}
+		bucket = &rateBucket{limitKey: limitKey, rate: float64(limit.Rate) / share, burst: max(float64(burst)/share, 1), updated: now}
+		bucket.tokens = bucket.burst
+		r.buckets[bucketKey] = bucket
+	}
+	return bucket.take(now, count)
+}
+
+func (r *rateLimiter) removeBuckets(limitKey string) {
+	for key, bucket := range r.buckets {
+		if bucket.limitKey == limitKey {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+func (r *rateLimiter) getLimitKey(orgID string, appID string) string {
+	return fmt.Sprintf("%s_%s", orgID, appID)
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{limits: map[string]model.FirebaseRateLimit{}, buckets: map[string]*rateBucket{}}
+}
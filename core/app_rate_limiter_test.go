@@ -0,0 +1,43 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"notifications/core/model"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterGivesTheRetryTimeOfEveryItem(t *testing.T) {
+	limiter := newRateLimiter()
+	limiter.setLimits([]model.FirebaseConf{{OrgID: "org", AppID: "app", RateLimit: &model.FirebaseRateLimit{Rate: 1, Burst: 1}}})
+
+	if retry := limiter.take("org", "app", 0, 1, testNow, 1); retry != nil {
+		t.Fatalf("the first item is deferred until %s, expected it to be sent", retry)
+	}
+	first := limiter.take("org", "app", 0, 1, testNow, 1)
+	second := limiter.take("org", "app", 0, 1, testNow, 1)
+	if first == nil || second == nil {
+		t.Fatalf("the items over the limit have not been deferred")
+	}
+
+	//the next take must not move the time given for the previous item
+	if expected := testNow.Add(time.Second); !first.Equal(expected) {
+		t.Errorf("the first deferred item is retried at %s, expected %s", first, expected)
+	}
+	if expected := testNow.Add(2 * time.Second); !second.Equal(expected) {
+		t.Errorf("the second deferred item is retried at %s, expected %s", second, expected)
+	}
+}
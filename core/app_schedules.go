@@ -0,0 +1,163 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"notifications/core/model"
+	"notifications/driven/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+)
+
+const (
+	//scheduleCheckInterval is the longest time the scheduler waits before checking the schedules again,
+	//so that the schedules created or resumed by other instances are picked up
+	scheduleCheckInterval time.Duration = time.Minute
+	//scheduleMinWait keeps the scheduler from spinning on runs which keep failing
+	scheduleMinWait time.Duration = 5 * time.Second
+	//scheduleProcessLimit is how many due schedules are processed at once
+	scheduleProcessLimit int64 = 100
+)
+
+// scheduleLogic creates the messages of the recurring schedules. Every instance runs it - a run is recorded together with
+// its message in one transaction and only if it has not been recorded yet, so every run creates exactly one message.
+// The created messages are delivered by the queue as any other message.
+type scheduleLogic struct {
+	logger *logs.Logger
+
+	app *Application
+
+	timerDone chan bool
+	updated   chan bool
+}
+
+func (s *scheduleLogic) start() {
+	s.logger.Info("scheduleLogic start")
+
+	go s.run()
+}
+
+// stop aborts the schedule timer
+func (s *scheduleLogic) stop() {
+	s.logger.Info("scheduleLogic stop")
+
+	close(s.timerDone)
+}
+
+// onSchedulesUpdated wakes up the scheduler so that it waits for the earliest run
+func (s *scheduleLogic) onSchedulesUpdated() {
+	select {
+	case s.updated <- true:
+	default: //already notified
+	}
+}
+
+func (s *scheduleLogic) run() {
+	for {
+		s.processDueSchedules()
+
+		timer := s.app.clock.NewTimer(s.getWaitDuration())
+		select {
+		case <-timer.C():
+		case <-s.updated:
+			timer.Stop()
+		case <-s.timerDone:
+			// timer aborted
+			s.logger.Info("scheduleLogic -> schedule timer aborted")
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (s *scheduleLogic) processDueSchedules() {
+	now := s.app.clock.Now().UTC()
+	schedules, err := s.app.storage.FindActiveMessageSchedules(&now, scheduleProcessLimit)
+	if err != nil {
+		s.logger.Errorf("error on finding the due message schedules - %s", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		err = s.runSchedule(schedule, now)
+		if err != nil {
+			s.logger.Errorf("error on running message schedule %s - %s", schedule.ID, err)
+		}
+	}
+}
+
+// runSchedule creates the message for the due run of the schedule. The runs missed while no instance has been running are not made,
+// the schedule continues with its first run after now.
+func (s *scheduleLogic) runSchedule(schedule model.MessageSchedule, now time.Time) error {
+	runAt := *schedule.NextRunAt
+	nextRunAt, err := schedule.GetNextRun(now)
+	if err != nil {
+		s.logger.Errorf("error on calculating the next run of message schedule %s, so it will not run anymore - %s", schedule.ID, err)
+		nextRunAt = nil
+	}
+
+	messageID := uuid.NewString()
+	inputMessage := schedule.Message.NewInputMessage(schedule.OrgID, schedule.AppID, messageID, runAt)
+
+	notifyQueue := false
+	//in transaction
+	transaction := func(context storage.TransactionContext) error {
+		//record the run, it is skipped if another instance has already made it
+		recorded, err := s.app.storage.UpdateMessageScheduleRunWithContext(context, schedule.ID, runAt, nextRunAt, messageID)
+		if err != nil {
+			return err
+		}
+		if !recorded {
+			return nil
+		}
+
+		_, notifyQueue, err = s.app.sharedCreateMessagesWithContext(context, []model.InputMessage{inputMessage})
+		return err
+	}
+
+	//perform transactions
+	err = s.app.storage.PerformTransaction(transaction, 10000) //10 seconds timeout
+	if err != nil {
+		return err
+	}
+
+	//notify the queue that new items are added
+	if notifyQueue {
+		go s.app.queueLogic.onQueuePush()
+	}
+	return nil
+}
+
+// getWaitDuration gives how long to wait for the earliest run
+func (s *scheduleLogic) getWaitDuration() time.Duration {
+	schedules, err := s.app.storage.FindActiveMessageSchedules(nil, 1)
+	if err != nil {
+		s.logger.Errorf("error on finding the upcoming message schedule - %s", err)
+		return scheduleCheckInterval
+	}
+	if len(schedules) == 0 {
+		return scheduleCheckInterval
+	}
+
+	duration := schedules[0].NextRunAt.Sub(s.app.clock.Now())
+	return min(max(duration, scheduleMinWait), scheduleCheckInterval)
+}
+
+// newScheduleLogic creates new scheduleLogic
+func newScheduleLogic(logger *logs.Logger, app *Application) *scheduleLogic {
+	return &scheduleLogic{logger: logger, app: app, timerDone: make(chan bool), updated: make(chan bool, 1)}
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"notifications/core/model"
+	"testing"
+	"time"
+)
+
+func TestScheduleWaitsForEarliestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		nextRuns []time.Duration //from now
+		paused   bool
+		expected time.Duration
+	}{
+		{name: "no schedules", expected: scheduleCheckInterval},
+		{name: "upcoming run", nextRuns: []time.Duration{50 * time.Second, 30 * time.Second}, expected: 30 * time.Second},
+		{name: "far run", nextRuns: []time.Duration{time.Hour}, expected: scheduleCheckInterval},
+		{name: "missed run", nextRuns: []time.Duration{-time.Hour}, expected: scheduleMinWait},
+		{name: "paused schedule", nextRuns: []time.Duration{30 * time.Second}, paused: true, expected: scheduleCheckInterval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp(t, testNow)
+			for i, nextRun := range tt.nextRuns {
+				nextRunAt := testNow.Add(nextRun)
+				status := model.MessageScheduleStatusActive
+				if tt.paused {
+					status = model.MessageScheduleStatusPaused
+				}
+				ta.storage.schedules = append(ta.storage.schedules, model.MessageSchedule{ID: string(rune('a' + i)), Status: status, NextRunAt: &nextRunAt})
+			}
+
+			if duration := ta.app.scheduleLogic.getWaitDuration(); duration != tt.expected {
+				t.Errorf("waits %s, expected %s", duration, tt.expected)
+			}
+		})
+	}
+}
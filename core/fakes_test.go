@@ -0,0 +1,695 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"notifications/core/model"
+	"notifications/driven/storage"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+)
+
+// fakeClock is a Clock which moves only when it is advanced
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	return c.addTimer(d, nil)
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.addTimer(d, f)
+}
+
+func (c *fakeClock) addTimer(d time.Duration, f func()) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &fakeTimer{clock: c, when: c.now.Add(d), active: true, c: make(chan time.Time, 1), f: f}
+	c.timers = append(c.timers, timer)
+	c.fireDueTimers()
+	return timer
+}
+
+// Advance moves the clock and fires the timers which have become due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	c.fireDueTimers()
+}
+
+// ActiveTimers gives the times when the active timers expire
+func (c *fakeClock) ActiveTimers() []time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := []time.Time{}
+	for _, timer := range c.timers {
+		if timer.active {
+			result = append(result, timer.when)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+// TimersCount gives how many timers have been created
+func (c *fakeClock) TimersCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+func (c *fakeClock) fireDueTimers() {
+	for _, timer := range c.timers {
+		if !timer.active || timer.when.After(c.now) {
+			continue
+		}
+
+		timer.active = false
+		if timer.f != nil {
+			go timer.f()
+			continue
+		}
+		select {
+		case timer.c <- c.now:
+		default: //the previous expiration has not been received
+		}
+	}
+}
+
+// fakeTimer is a timer created by fakeClock
+type fakeTimer struct {
+	clock  *fakeClock
+	when   time.Time
+	active bool
+	c      chan time.Time
+	f      func()
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	if t.f != nil {
+		return nil
+	}
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.active = false
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.active = true
+	t.when = t.clock.now.Add(d)
+	t.clock.fireDueTimers()
+	return wasActive
+}
+
+// fakeStorage keeps the data used by the scheduling logic in memory. The methods which are not needed by the tests are not implemented.
+type fakeStorage struct {
+	Storage
+
+	mu sync.Mutex
+
+	users        []model.User
+	unreadCounts []model.UserUnreadCount
+	queues       map[string]model.Queue
+	queueData    map[string]model.QueueItem
+	pauses       []model.QueuePause
+	schedules    []model.MessageSchedule
+	attempts     []model.DeliveryAttempt
+	deadLetter   []model.QueueDeadLetter
+	deletions    []string //org_app_accounts of the deleted users data
+	prunes       []model.TokenPrune
+
+	findQueuesCount   int
+	transactionsCount int
+
+	releaseLeaseErrors int //how many times releasing the queue lease fails before it succeeds
+	releaseLeaseCount  int
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{queues: map[string]model.Queue{}, queueData: map[string]model.QueueItem{}}
+}
+
+func (s *fakeStorage) addQueueItems(items ...model.QueueItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range items {
+		s.queueData[item.ID] = item
+	}
+}
+
+func (s *fakeStorage) getQueueItem(id string) (model.QueueItem, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, exists := s.queueData[id]
+	return item, exists
+}
+
+func (s *fakeStorage) RegisterStorageListener(storageListener storage.Listener) {}
+
+func (s *fakeStorage) PerformTransaction(transaction func(context storage.TransactionContext) error, timeoutMilliSeconds int64) error {
+	s.mu.Lock()
+	s.transactionsCount++
+	s.mu.Unlock()
+
+	return transaction(nil)
+}
+
+func (s *fakeStorage) LoadFirebaseConfigurations() ([]model.FirebaseConf, error) {
+	return nil, nil
+}
+
+func (s *fakeStorage) FindUsersByIDs(usersIDs []string) ([]model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []model.User{}
+	for _, user := range s.users {
+		for _, id := range usersIDs {
+			if user.UserID == id {
+				result = append(result, user)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStorage) FindUserByID(orgID string, appID string, userID string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.OrgID == orgID && user.AppID == appID && user.UserID == userID {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeStorage) RemoveFirebaseTokenFromUser(orgID string, appID string, userID string, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, user := range s.users {
+		if user.OrgID != orgID || user.AppID != appID || user.UserID != userID {
+			continue
+		}
+		tokens := []model.FirebaseToken{}
+		for _, fToken := range user.FirebaseTokens {
+			if fToken.Token != token {
+				tokens = append(tokens, fToken)
+			}
+		}
+		s.users[i].FirebaseTokens = tokens
+	}
+	return nil
+}
+
+func (s *fakeStorage) InsertTokenPrune(prune model.TokenPrune) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prunes = append(s.prunes, prune)
+	return nil
+}
+
+func (s *fakeStorage) FindUsersUnreadCounts(usersIDs []string) ([]model.UserUnreadCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []model.UserUnreadCount{}
+	for _, unreadCount := range s.unreadCounts {
+		if containsString(usersIDs, unreadCount.UserID) {
+			result = append(result, unreadCount)
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStorage) DeleteUsersWithIDs(ctx context.Context, orgID string, appID string, accountsIDs []string) error {
+	return s.recordDeletion("users", orgID, appID, accountsIDs)
+}
+
+func (s *fakeStorage) DeleteMessagesRecipientsForUsers(ctx context.Context, orgID string, appID string, accountsIDs []string) error {
+	return s.recordDeletion("recipients", orgID, appID, accountsIDs)
+}
+
+func (s *fakeStorage) DeleteQueueDataForUsers(ctx context.Context, orgID string, appID string, accountsIDs []string) error {
+	return s.recordDeletion("queue_data", orgID, appID, accountsIDs)
+}
+
+func (s *fakeStorage) recordDeletion(data string, orgID string, appID string, accountsIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deletion := data + ":" + orgID + "_" + appID
+	for _, id := range accountsIDs {
+		deletion += "_" + id
+	}
+	s.deletions = append(s.deletions, deletion)
+	return nil
+}
+
+func (s *fakeStorage) InsertQueueDataItemsWithContext(ctx context.Context, items []model.QueueItem) error {
+	s.addQueueItems(items...)
+	return nil
+}
+
+func (s *fakeStorage) LoadQueueWithContext(ctx context.Context, id string) (*model.Queue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queue, exists := s.queues[id]
+	if !exists {
+		return nil, nil
+	}
+	return &queue, nil
+}
+
+func (s *fakeStorage) SaveQueueWithContext(ctx context.Context, queue model.Queue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queues[queue.ID] = queue
+	return nil
+}
+
+func (s *fakeStorage) FindQueues() ([]model.Queue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.findQueuesCount++
+	result := []model.Queue{}
+	for _, queue := range s.queues {
+		result = append(result, queue)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Partition < result[j].Partition })
+	return result, nil
+}
+
+func (s *fakeStorage) getFindQueuesCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.findQueuesCount
+}
+
+func (s *fakeStorage) CreateQueueIfMissing(queue model.Queue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.queues[queue.ID]; !exists {
+		s.queues[queue.ID] = queue
+	}
+	return nil
+}
+
+func (s *fakeStorage) RenewQueueLease(queueID string, owner string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queue, exists := s.queues[queueID]
+	if !exists || queue.Status != model.QueueStatusProcessing || queue.LeaseOwner != owner {
+		return false, nil
+	}
+	queue.LeaseExpiresAt = &expiresAt
+	s.queues[queueID] = queue
+	return true, nil
+}
+
+func (s *fakeStorage) ReleaseQueueLease(queueID string, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.releaseLeaseCount++
+	if s.releaseLeaseCount <= s.releaseLeaseErrors {
+		return errors.New("release lease error")
+	}
+
+	queue, exists := s.queues[queueID]
+	if exists && queue.LeaseOwner == owner {
+		queue.Status = model.QueueStatusReady
+		queue.LeaseOwner = ""
+		queue.LeaseExpiresAt = nil
+		s.queues[queueID] = queue
+	}
+	return nil
+}
+
+func (s *fakeStorage) FindQueuePauses() ([]model.QueuePause, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]model.QueuePause{}, s.pauses...), nil
+}
+
+func (s *fakeStorage) FindQueueData(time *time.Time, partition int, partitionsCount int, byPriority bool, excludeIDs []string, pauses []model.QueuePause, limit int) ([]model.QueueItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []model.QueueItem{}
+	for _, item := range s.queueData {
+		if time != nil && item.Time.After(*time) {
+			continue
+		}
+		if partitionsCount > 1 && item.Slot%partitionsCount != partition {
+			continue
+		}
+		if containsString(excludeIDs, item.ID) || isPaused(pauses, item) {
+			continue
+		}
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if byPriority && a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		return a.Priority > b.Priority
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *fakeStorage) UpdateQueueDataTimes(times map[string]time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, time := range times {
+		if item, exists := s.queueData[id]; exists {
+			item.Time = time
+			s.queueData[id] = item
+		}
+	}
+	return nil
+}
+
+func (s *fakeStorage) FindQueueDataForDigest(orgID string, appID string, usersIDs []string, time time.Time) ([]model.QueueItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []model.QueueItem{}
+	for _, item := range s.queueData {
+		if item.OrgID == orgID && item.AppID == appID && containsString(usersIDs, item.UserID) && item.Digest && !item.Time.After(time) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStorage) DeleteQueueData(ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		delete(s.queueData, id)
+	}
+	return nil
+}
+
+func (s *fakeStorage) InsertDeliveryAttempts(items []model.DeliveryAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts = append(s.attempts, items...)
+	return nil
+}
+
+func (s *fakeStorage) InsertQueueDeadLetters(items []model.QueueDeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadLetter = append(s.deadLetter, items...)
+	return nil
+}
+
+func (s *fakeStorage) FindQueueDeadLettersWithContext(ctx context.Context, orgID string, appID string, ids []string, messageID *string, offset *int64, limit *int64) ([]model.QueueDeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []model.QueueDeadLetter{}
+	for _, item := range s.deadLetter {
+		if item.OrgID != orgID || item.AppID != appID || (ids != nil && !containsString(ids, item.ID)) ||
+			(messageID != nil && item.MessageID != *messageID) {
+			continue
+		}
+		result = append(result, item)
+	}
+	if offset != nil {
+		result = result[min(*offset, int64(len(result))):]
+	}
+	if limit != nil && int64(len(result)) > *limit {
+		result = result[:*limit]
+	}
+	return result, nil
+}
+
+func (s *fakeStorage) DeleteQueueDeadLettersWithContext(ctx context.Context, orgID string, appID string, ids []string, messageID *string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remaining := []model.QueueDeadLetter{}
+	for _, item := range s.deadLetter {
+		if item.OrgID == orgID && item.AppID == appID && (ids == nil || containsString(ids, item.ID)) &&
+			(messageID == nil || item.MessageID == *messageID) {
+			continue
+		}
+		remaining = append(remaining, item)
+	}
+	deleted := len(s.deadLetter) - len(remaining)
+	s.deadLetter = remaining
+	return int64(deleted), nil
+}
+
+func (s *fakeStorage) FindActiveMessageSchedules(dueTime *time.Time, limit int64) ([]model.MessageSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []model.MessageSchedule{}
+	for _, schedule := range s.schedules {
+		if schedule.Status != model.MessageScheduleStatusActive || schedule.NextRunAt == nil {
+			continue
+		}
+		if dueTime != nil && schedule.NextRunAt.After(*dueTime) {
+			continue
+		}
+		result = append(result, schedule)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].NextRunAt.Before(*result[j].NextRunAt) })
+
+	if limit > 0 && int64(len(result)) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// fakeFirebase records the sent notifications
+type fakeFirebase struct {
+	Firebase
+
+	mu   sync.Mutex
+	sent []model.FirebaseMessage
+}
+
+func (f *fakeFirebase) UpdateFirebaseConfigurations(firebaseConfs []model.FirebaseConf) error {
+	return nil
+}
+
+func (f *fakeFirebase) SendNotifications(orgID string, appID string, messages []model.FirebaseMessage) ([]model.FirebaseSendResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	results := make([]model.FirebaseSendResult, len(messages))
+	for i, message := range messages {
+		f.sent = append(f.sent, message)
+		results[i] = model.FirebaseSendResult{Token: message.Token, MessageID: "fcm_" + message.Title}
+	}
+	return results, nil
+}
+
+// sentMessages gives the sent notifications in the order they have been sent
+func (f *fakeFirebase) sentMessages() []model.FirebaseMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]model.FirebaseMessage{}, f.sent...)
+}
+
+// sentTitles gives the titles of the sent notifications in the order they have been sent
+func (f *fakeFirebase) sentTitles() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]string, len(f.sent))
+	for i, message := range f.sent {
+		result[i] = message.Title
+	}
+	return result
+}
+
+// fakeCore gives the configured deleted memberships
+type fakeCore struct {
+	mu                 sync.Mutex
+	deletedMemberships []model.DeletedUserData
+	loadsCount         int
+}
+
+func (c *fakeCore) RetrieveCoreUserAccountByCriteria(accountCriteria map[string]interface{}, appID *string, orgID *string) ([]model.CoreAccount, error) {
+	return nil, nil
+}
+
+func (c *fakeCore) LoadDeletedMemberships() ([]model.DeletedUserData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.loadsCount++
+	return c.deletedMemberships, nil
+}
+
+func (c *fakeCore) getLoadsCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.loadsCount
+}
+
+// testApp is an application with the fakes
+type testApp struct {
+	app      *Application
+	clock    *fakeClock
+	storage  *fakeStorage
+	firebase *fakeFirebase
+	core     *fakeCore
+}
+
+func newTestApp(t *testing.T, now time.Time) *testApp {
+	return newTestAppWithStorage(t, now, newFakeStorage())
+}
+
+// newTestAppWithStorage creates a test app on existing data, as an instance started after another one
+func newTestAppWithStorage(t *testing.T, now time.Time, storage *fakeStorage) *testApp {
+	logger := logs.NewLogger("notifications_test", nil)
+	logger.SetLevel(logs.Warn)
+
+	clock := &fakeClock{now: now}
+	firebase := &fakeFirebase{}
+	core := &fakeCore{}
+	queueConfig := model.QueueConfig{WorkersCount: 1, BufferSize: 10, PartitionsCount: 1}
+
+	app := NewApplication("test", "test", storage, firebase, nil, logger, core, queueConfig, clock)
+	return &testApp{app: app, clock: clock, storage: storage, firebase: firebase, core: core}
+}
+
+// startQueue starts the queue logic and stops it at the end of the test
+func (ta *testApp) startQueue(t *testing.T) {
+	ta.app.queueLogic.start()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := ta.app.queueLogic.stop(ctx)
+		if err != nil {
+			t.Errorf("error on stopping the queue - %s", err)
+		}
+	})
+}
+
+func (ta *testApp) addUser(userID string) {
+	ta.storage.mu.Lock()
+	defer ta.storage.mu.Unlock()
+
+	user := model.User{OrgID: "org", AppID: "app", ID: userID, UserID: userID, FirebaseTokens: []model.FirebaseToken{{Token: "token_" + userID}}}
+	ta.storage.users = append(ta.storage.users, user)
+}
+
+func newTestQueueItem(id string, userID string, itemTime time.Time, priority int) model.QueueItem {
+	return model.QueueItem{OrgID: "org", AppID: "app", ID: id, MessageID: "message_" + id, UserID: userID,
+		Subject: id, Body: "body", Time: itemTime, Priority: priority, Slot: model.GetQueueSlot(userID)}
+}
+
+// waitFor waits for the asynchronous processing until the condition is met
+func waitFor(t *testing.T, description string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout on waiting for %s", description)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isPaused(pauses []model.QueuePause, item model.QueueItem) bool {
+	for _, pause := range pauses {
+		if pause.OrgID == item.OrgID && pause.AppID == item.AppID {
+			return true
+		}
+	}
+	return false
+}
+
+func stringPointer(value string) *string {
+	return &value
+}
@@ -33,11 +33,11 @@ type Services interface {
 	AppendTopic(*model.Topic) (*model.Topic, error)
 	UpdateTopic(*model.Topic) (*model.Topic, error)
 	FindUserByID(orgID string, appID string, userID string) (*model.User, error)
-	UpdateUserByID(orgID string, appID string, userID string, notificationsEnabled bool) (*model.User, error)
+	UpdateUserByID(orgID string, appID string, userID string, notificationsEnabled bool, quietHours *model.QuietHours, timeZone *string, digest *model.DigestSettings) (*model.User, error)
 	DeleteUserWithID(orgID string, appID string, userID string) error
 	GetUserData(orgID string, appID string, userID string) (*model.UserDataResponse, error)
 
-	GetMessagesRecipientsDeep(orgID string, appID string, userID *string, read *bool, mute *bool, messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string, offset *int64, limit *int64, order *string) ([]model.MessageRecipient, error)
+	GetMessagesRecipientsDeep(orgID string, appID string, userID *string, read *bool, mute *bool, messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string, includeExpired bool, offset *int64, limit *int64, order *string) ([]model.MessageRecipient, error)
 
 	GetMessagesStats(orgID string, appID string, userID string) (*model.MessagesStats, error)
 	GetMessage(orgID string, appID string, ID string) (*model.Message, error)
@@ -91,8 +91,8 @@ func (s *servicesImpl) UpdateTopic(topic *model.Topic) (*model.Topic, error) {
 	return s.app.updateTopic(topic)
 }
 
-func (s *servicesImpl) GetMessagesRecipientsDeep(orgID string, appID string, userID *string, read *bool, mute *bool, messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string, offset *int64, limit *int64, order *string) ([]model.MessageRecipient, error) {
-	return s.app.getMessagesRecipientsDeep(orgID, appID, userID, read, mute, messageIDs, startDateEpoch, endDateEpoch, filterTopic, offset, limit, order)
+func (s *servicesImpl) GetMessagesRecipientsDeep(orgID string, appID string, userID *string, read *bool, mute *bool, messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string, includeExpired bool, offset *int64, limit *int64, order *string) ([]model.MessageRecipient, error) {
+	return s.app.getMessagesRecipientsDeep(orgID, appID, userID, read, mute, messageIDs, startDateEpoch, endDateEpoch, filterTopic, includeExpired, offset, limit, order)
 }
 
 func (s *servicesImpl) GetMessagesStats(orgID string, appID string, userID string) (*model.MessagesStats, error) {
@@ -143,8 +143,8 @@ func (s *servicesImpl) FindUserByID(orgID string, appID string, userID string) (
 	return s.app.findUserByID(orgID, appID, userID)
 }
 
-func (s *servicesImpl) UpdateUserByID(orgID string, appID string, userID string, notificationsEnabled bool) (*model.User, error) {
-	return s.app.updateUserByID(orgID, appID, userID, notificationsEnabled)
+func (s *servicesImpl) UpdateUserByID(orgID string, appID string, userID string, notificationsEnabled bool, quietHours *model.QuietHours, timeZone *string, digest *model.DigestSettings) (*model.User, error) {
+	return s.app.updateUserByID(orgID, appID, userID, notificationsEnabled, quietHours, timeZone, digest)
 }
 
 func (s *servicesImpl) DeleteUserWithID(orgID string, appID string, userID string) error {
@@ -158,6 +158,27 @@ func (s *servicesImpl) SendMail(toEmail string, subject string, body string) err
 // Admin exposes APIs for the driver adapters
 type Admin interface {
 	AdminGetMessagesStats(orgID string, appID string, adminAccountID string, source string, offset *int64, limit *int64, order *string) (map[int][]interface{}, error)
+	AdminGetDeliveryAttempts(orgID string, appID string, messageID *string, userID *string, offset *int64, limit *int64) ([]model.DeliveryAttempt, error)
+
+	AdminRescheduleMessage(orgID string, appID string, id string, messageTime time.Time) (*model.Message, error)
+	AdminCancelMessage(orgID string, appID string, id string, cancelledBy model.Sender) (*model.MessageCancellation, error)
+	AdminGetMessageCancellations(orgID string, appID string, messageID *string, offset *int64, limit *int64) ([]model.MessageCancellation, error)
+
+	AdminGetQueue(orgID string, appID string, messageID *string) (*model.QueueOverview, error)
+	AdminPauseQueue(orgID string, appID string, accountID string) (*model.QueuePause, error)
+	AdminResumeQueue(orgID string, appID string) (bool, error)
+
+	AdminGetQueueDeadLetters(orgID string, appID string, messageID *string, offset *int64, limit *int64) ([]model.QueueDeadLetter, error)
+	AdminGetQueueDeadLetter(orgID string, appID string, id string) (*model.QueueDeadLetter, error)
+	AdminReplayQueueDeadLetters(orgID string, appID string, id *string, messageID *string, limit *int64) (int, error)
+	AdminDeleteQueueDeadLetters(orgID string, appID string, id *string, messageID *string) (int64, error)
+
+	AdminCreateMessageSchedule(orgID string, appID string, cron string, timeZone string, message model.MessageTemplate) (*model.MessageSchedule, error)
+	AdminGetMessageSchedules(orgID string, appID string, status *string, offset *int64, limit *int64) ([]model.MessageSchedule, error)
+	AdminGetMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error)
+	AdminPauseMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error)
+	AdminResumeMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error)
+	AdminDeleteMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error)
 }
 
 type adminImpl struct {
@@ -168,6 +189,74 @@ func (s *adminImpl) AdminGetMessagesStats(orgID string, appID string, adminAccou
 	return s.app.adminGetMessagesStats(orgID, appID, adminAccountID, source, offset, limit, order)
 }
 
+func (s *adminImpl) AdminGetDeliveryAttempts(orgID string, appID string, messageID *string, userID *string, offset *int64, limit *int64) ([]model.DeliveryAttempt, error) {
+	return s.app.adminGetDeliveryAttempts(orgID, appID, messageID, userID, offset, limit)
+}
+
+func (s *adminImpl) AdminRescheduleMessage(orgID string, appID string, id string, messageTime time.Time) (*model.Message, error) {
+	return s.app.adminRescheduleMessage(orgID, appID, id, messageTime)
+}
+
+func (s *adminImpl) AdminCancelMessage(orgID string, appID string, id string, cancelledBy model.Sender) (*model.MessageCancellation, error) {
+	return s.app.adminCancelMessage(orgID, appID, id, cancelledBy)
+}
+
+func (s *adminImpl) AdminGetMessageCancellations(orgID string, appID string, messageID *string, offset *int64, limit *int64) ([]model.MessageCancellation, error) {
+	return s.app.adminGetMessageCancellations(orgID, appID, messageID, offset, limit)
+}
+
+func (s *adminImpl) AdminGetQueue(orgID string, appID string, messageID *string) (*model.QueueOverview, error) {
+	return s.app.adminGetQueue(orgID, appID, messageID)
+}
+
+func (s *adminImpl) AdminPauseQueue(orgID string, appID string, accountID string) (*model.QueuePause, error) {
+	return s.app.adminPauseQueue(orgID, appID, accountID)
+}
+
+func (s *adminImpl) AdminResumeQueue(orgID string, appID string) (bool, error) {
+	return s.app.adminResumeQueue(orgID, appID)
+}
+
+func (s *adminImpl) AdminGetQueueDeadLetters(orgID string, appID string, messageID *string, offset *int64, limit *int64) ([]model.QueueDeadLetter, error) {
+	return s.app.adminGetQueueDeadLetters(orgID, appID, messageID, offset, limit)
+}
+
+func (s *adminImpl) AdminGetQueueDeadLetter(orgID string, appID string, id string) (*model.QueueDeadLetter, error) {
+	return s.app.adminGetQueueDeadLetter(orgID, appID, id)
+}
+
+func (s *adminImpl) AdminReplayQueueDeadLetters(orgID string, appID string, id *string, messageID *string, limit *int64) (int, error) {
+	return s.app.adminReplayQueueDeadLetters(orgID, appID, id, messageID, limit)
+}
+
+func (s *adminImpl) AdminDeleteQueueDeadLetters(orgID string, appID string, id *string, messageID *string) (int64, error) {
+	return s.app.adminDeleteQueueDeadLetters(orgID, appID, id, messageID)
+}
+
+func (s *adminImpl) AdminCreateMessageSchedule(orgID string, appID string, cron string, timeZone string, message model.MessageTemplate) (*model.MessageSchedule, error) {
+	return s.app.adminCreateMessageSchedule(orgID, appID, cron, timeZone, message)
+}
+
+func (s *adminImpl) AdminGetMessageSchedules(orgID string, appID string, status *string, offset *int64, limit *int64) ([]model.MessageSchedule, error) {
+	return s.app.adminGetMessageSchedules(orgID, appID, status, offset, limit)
+}
+
+func (s *adminImpl) AdminGetMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error) {
+	return s.app.adminGetMessageSchedule(orgID, appID, id)
+}
+
+func (s *adminImpl) AdminPauseMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error) {
+	return s.app.adminPauseMessageSchedule(orgID, appID, id)
+}
+
+func (s *adminImpl) AdminResumeMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error) {
+	return s.app.adminResumeMessageSchedule(orgID, appID, id)
+}
+
+func (s *adminImpl) AdminDeleteMessageSchedule(orgID string, appID string, id string) (*model.MessageSchedule, error) {
+	return s.app.adminDeleteMessageSchedule(orgID, appID, id)
+}
+
 // BBs exposes users related APIs used by the platform building blocks
 type BBs interface {
 	BBsCreateMessages(inputMessages []model.InputMessage) ([]model.Message, error)
@@ -175,6 +264,14 @@ type BBs interface {
 	BBsSendMail(toEmail string, subject string, body string) error
 	BBsAddRecipients(l *logs.Log, serviceAccountID string, messageID string, recipients []model.InputMessageRecipient) ([]model.MessageRecipient, error)
 	BBsDeleteRecipients(l *logs.Log, serviceAccountID string, messageID string, usersIDs []string) error
+	BBsRescheduleMessage(serviceAccountID string, messageID string, messageTime time.Time) (*model.Message, error)
+	BBsCancelMessage(serviceAccountID string, messageID string, cancelledBy model.Sender) (*model.MessageCancellation, error)
+
+	BBsCreateMessageSchedule(orgID string, appID string, cron string, timeZone string, message model.MessageTemplate) (*model.MessageSchedule, error)
+	BBsGetMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error)
+	BBsPauseMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error)
+	BBsResumeMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error)
+	BBsDeleteMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error)
 }
 
 type bbsImpl struct {
@@ -201,6 +298,67 @@ func (s *bbsImpl) BBsDeleteRecipients(l *logs.Log, serviceAccountID string, mess
 	return s.app.bbsDeleteRecipients(l, serviceAccountID, messageID, usersIDs)
 }
 
+func (s *bbsImpl) BBsRescheduleMessage(serviceAccountID string, messageID string, messageTime time.Time) (*model.Message, error) {
+	return s.app.bbsRescheduleMessage(serviceAccountID, messageID, messageTime)
+}
+
+func (s *bbsImpl) BBsCancelMessage(serviceAccountID string, messageID string, cancelledBy model.Sender) (*model.MessageCancellation, error) {
+	return s.app.bbsCancelMessage(serviceAccountID, messageID, cancelledBy)
+}
+
+func (s *bbsImpl) BBsCreateMessageSchedule(orgID string, appID string, cron string, timeZone string, message model.MessageTemplate) (*model.MessageSchedule, error) {
+	return s.app.bbsCreateMessageSchedule(orgID, appID, cron, timeZone, message)
+}
+
+func (s *bbsImpl) BBsGetMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error) {
+	return s.app.bbsGetMessageSchedule(serviceAccountID, id)
+}
+
+func (s *bbsImpl) BBsPauseMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error) {
+	return s.app.bbsPauseMessageSchedule(serviceAccountID, id)
+}
+
+func (s *bbsImpl) BBsResumeMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error) {
+	return s.app.bbsResumeMessageSchedule(serviceAccountID, id)
+}
+
+func (s *bbsImpl) BBsDeleteMessageSchedule(serviceAccountID string, id string) (*model.MessageSchedule, error) {
+	return s.app.bbsDeleteMessageSchedule(serviceAccountID, id)
+}
+
+// System exposes the APIs which control the whole service for the driver adapters
+type System interface {
+	SystemGetQueue() (*model.QueueState, error)
+	SystemUpdateQueue(processItemsCount int) error
+	SystemGetQueueLeases() ([]model.Queue, error)
+	SystemUpdateQueuePartition(id string, processItemsCount int) error
+	SystemProcessQueue()
+}
+
+type systemImpl struct {
+	app *Application
+}
+
+func (s *systemImpl) SystemGetQueue() (*model.QueueState, error) {
+	return s.app.systemGetQueue()
+}
+
+func (s *systemImpl) SystemUpdateQueue(processItemsCount int) error {
+	return s.app.systemUpdateQueue(processItemsCount)
+}
+
+func (s *systemImpl) SystemGetQueueLeases() ([]model.Queue, error) {
+	return s.app.systemGetQueueLeases()
+}
+
+func (s *systemImpl) SystemUpdateQueuePartition(id string, processItemsCount int) error {
+	return s.app.systemUpdateQueuePartition(id, processItemsCount)
+}
+
+func (s *systemImpl) SystemProcessQueue() {
+	s.app.systemProcessQueue()
+}
+
 // Storage is used by core to storage data - DB storage adapter, file storage adapter etc
 type Storage interface {
 	RegisterStorageListener(storageListener storage.Listener)
@@ -211,7 +369,7 @@ type Storage interface {
 
 	FindUsersByIDs(usersIDs []string) ([]model.User, error)
 	FindUserByID(orgID string, appID string, userID string) (*model.User, error)
-	UpdateUserByID(orgID string, appID string, userID string, notificationsEnabled bool) (*model.User, error)
+	UpdateUserByID(orgID string, appID string, userID string, notificationsEnabled bool, quietHours *model.QuietHours, timeZone *string, digest *model.DigestSettings) (*model.User, error)
 	DeleteUserWithID(orgID string, appID string, userID string) error
 	DeleteUsersWithIDs(ctx context.Context, orgID string, appID string, accountsIDs []string) error
 
@@ -229,13 +387,14 @@ type Storage interface {
 	FindMessagesRecipients(orgID string, appID string, messageID string, userID string) ([]model.MessageRecipient, error)
 	FindMessagesRecipientsByMessageAndUsers(messageID string, usersIDs []string) ([]model.MessageRecipient, error)
 	FindMessagesRecipientsByMessages(messagesIDs []string) ([]model.MessageRecipient, error)
-	FindMessagesRecipientsDeep(orgID string, appID string, userID *string, read *bool, mute *bool, messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string, offset *int64, limit *int64, order *string) ([]model.MessageRecipient, error)
+	FindMessagesRecipientsDeep(orgID string, appID string, userID *string, read *bool, mute *bool, messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string, includeExpired bool, offset *int64, limit *int64, order *string) ([]model.MessageRecipient, error)
 	FindMessagesRecipientsByUserID(orgID string, appID string, userID string) ([]model.MessageRecipient, error)
 
 	InsertMessagesRecipientsWithContext(ctx context.Context, items []model.MessageRecipient) error
 	DeleteMessagesRecipientsForIDsWithContext(ctx context.Context, ids []string) error
 	DeleteMessagesRecipientsForMessagesWithContext(ctx context.Context, messagesIDs []string) error
 	DeleteMessagesRecipientsForUsers(ctx context.Context, orgID string, appID string, accountsIDs []string) error
+	SupersedeMessagesRecipientsWithContext(ctx context.Context, orgID string, appID string, collapseKey string, usersIDs []string) error
 
 	FindMessagesWithContext(ctx context.Context, ids []string) ([]model.Message, error)
 	FindMessagesByParams(orgID string, appID string, senderType string, senderAccountID *string, offset *int64, limit *int64, order *string) ([]model.Message, error)
@@ -245,29 +404,68 @@ type Storage interface {
 	UpdateMessage(message *model.Message) (*model.Message, error)
 	DeleteUserMessageWithContext(ctx context.Context, orgID string, appID string, userID string, messageID string) error
 	DeleteMessagesWithContext(ctx context.Context, ids []string) error
+	UpdateMessageTimeWithContext(ctx context.Context, id string, messageTime time.Time, expiresAt *time.Time) error
+	InsertMessageCancellationWithContext(ctx context.Context, item model.MessageCancellation) error
+	FindMessageCancellations(orgID string, appID string, messageID *string, offset *int64, limit *int64) ([]model.MessageCancellation, error)
 	GetMessagesStats(orgID string, appID string, userID string) (*model.MessagesStats, error)
+	FindUsersUnreadCounts(usersIDs []string) ([]model.UserUnreadCount, error)
 	UpdateUnreadMessage(ctx context.Context, orgID string, appID string, ID string, userID string) (*model.Message, error)
 	UpdateAllUserMessagesRead(ctx context.Context, orgID string, appID string, userID string, read bool) error
 	GetAllAppVersions(orgID string, appID string) ([]model.AppVersion, error)
 	GetAllAppPlatforms(orgID string, appID string) ([]model.AppPlatform, error)
 
 	InsertQueueDataItemsWithContext(ctx context.Context, items []model.QueueItem) error
-	LoadQueueWithContext(ctx context.Context) (*model.Queue, error)
+	LoadQueueWithContext(ctx context.Context, id string) (*model.Queue, error)
 	SaveQueueWithContext(ctx context.Context, queue model.Queue) error
-	SaveQueue(queue model.Queue) error
-
-	FindQueueData(time *time.Time, limit int) ([]model.QueueItem, error)
+	FindQueues() ([]model.Queue, error)
+	CreateQueueIfMissing(queue model.Queue) error
+	UpdateQueueProcessItemsCount(id string, processItemsCount int) error
+	RenewQueueLease(queueID string, owner string, expiresAt time.Time) (bool, error)
+	ReleaseQueueLease(queueID string, owner string) error
+
+	FindQueuePauses() ([]model.QueuePause, error)
+	FindQueuePause(orgID string, appID string) (*model.QueuePause, error)
+	CreateQueuePauseIfMissing(pause model.QueuePause) error
+	DeleteQueuePause(orgID string, appID string) (bool, error)
+
+	FindQueueData(time *time.Time, partition int, partitionsCount int, byPriority bool, excludeIDs []string, pauses []model.QueuePause, limit int) ([]model.QueueItem, error)
+	FindQueueDataPending(orgID string, appID string, messageID *string) ([]model.QueueMessagePending, error)
 	FindQueueDataByUserID(userID string) ([]model.QueueItem, error)
+	UpdateQueueDataTimes(times map[string]time.Time) error
+	UpdateQueueDataForDigest(times map[string]time.Time) error
+	FindQueueDataForDigest(orgID string, appID string, usersIDs []string, time time.Time) ([]model.QueueItem, error)
+	DeleteQueueDataForCollapseKeyWithContext(ctx context.Context, orgID string, appID string, collapseKey string, usersIDs []string) error
 	DeleteQueueData(ids []string) error
+	UpdateQueueDataTimeForMessageWithContext(ctx context.Context, messageID string, messageTime time.Time, expiresAt *time.Time) error
+	CountQueueDataForMessageWithContext(ctx context.Context, messageID string) (int64, error)
 	DeleteQueueDataForMessagesWithContext(ctx context.Context, messagesIDs []string) error
 	DeleteQueueDataForRecipientsWithContext(ctx context.Context, recipientsIDs []string) error
 	DeleteQueueDataForUsers(ctx context.Context, orgID string, appID string, accountsIDs []string) error
+
+	InsertDeliveryAttempts(items []model.DeliveryAttempt) error
+	FindDeliveryAttempts(orgID string, appID string, messageID *string, userID *string, offset *int64, limit *int64) ([]model.DeliveryAttempt, error)
+
+	RemoveFirebaseTokenFromUser(orgID string, appID string, userID string, token string) error
+	InsertTokenPrune(item model.TokenPrune) error
+
+	InsertQueueDeadLetters(items []model.QueueDeadLetter) error
+	FindQueueDeadLettersWithContext(ctx context.Context, orgID string, appID string, ids []string, messageID *string, offset *int64, limit *int64) ([]model.QueueDeadLetter, error)
+	DeleteQueueDeadLettersWithContext(ctx context.Context, orgID string, appID string, ids []string, messageID *string) (int64, error)
+
+	InsertMessageSchedule(schedule model.MessageSchedule) error
+	FindMessageSchedules(orgID string, appID string, status *string, offset *int64, limit *int64) ([]model.MessageSchedule, error)
+	FindMessageSchedule(id string) (*model.MessageSchedule, error)
+	FindActiveMessageSchedules(dueTime *time.Time, limit int64) ([]model.MessageSchedule, error)
+	UpdateMessageScheduleStatus(id string, status string, nextRunAt *time.Time) error
+	UpdateMessageScheduleRunWithContext(ctx context.Context, id string, runAt time.Time, nextRunAt *time.Time, messageID string) (bool, error)
+	DeleteMessageSchedule(id string) error
 }
 
 // Firebase is used to wrap all Firebase Messaging API functions
 type Firebase interface {
 	UpdateFirebaseConfigurations(firebaseConfs []model.FirebaseConf) error
-	SendNotificationToToken(orgID string, appID string, token string, title string, body string, data map[string]string) error
+	SendNotificationToToken(orgID string, appID string, token string, title string, body string, data map[string]string) (string, error)
+	SendNotifications(orgID string, appID string, messages []model.FirebaseMessage) ([]model.FirebaseSendResult, error)
 	SendNotificationToTopic(orgID string, appID string, topic string, title string, body string, data map[string]string) error
 	SubscribeToTopic(orgID string, appID string, token string, topic string) error
 	UnsubscribeToTopic(orgID string, appID string, token string, topic string) error
@@ -0,0 +1,184 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit is how many candidate times are checked when looking for the next run before giving up
+const cronSearchLimit int = 100000
+
+// cronField is the allowed range of a cron expression field
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7}, //both 0 and 7 are Sunday
+}
+
+// CronExpression represents a parsed standard 5 fields cron expression - minute hour day-of-month month day-of-week.
+// Every field supports *, single values, ranges(1-5), steps(*/15, 1-30/5) and lists of them(1,15,30).
+type CronExpression struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+
+	//the day is matched by any of the day fields when both of them are restricted
+	daysOfMonthAny bool
+	daysOfWeekAny  bool
+}
+
+// ParseCronExpression parses a standard 5 fields cron expression
+func ParseCronExpression(expression string) (*CronExpression, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %s, 5 fields expected", expression)
+	}
+
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		value, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	//Sunday could be given as 7
+	daysOfWeek := values[4]
+	if daysOfWeek&(1<<7) != 0 {
+		daysOfWeek = (daysOfWeek | 1) &^ (1 << 7)
+	}
+
+	return &CronExpression{minutes: values[0], hours: values[1], daysOfMonth: values[2], months: values[3], daysOfWeek: daysOfWeek,
+		daysOfMonthAny: strings.HasPrefix(fields[2], "*"), daysOfWeekAny: strings.HasPrefix(fields[4], "*")}, nil
+}
+
+// Next gives the first time after the given one which matches the expression in the location. It gives nil if there is no such time.
+func (c CronExpression) Next(after time.Time, location *time.Location) *time.Time {
+	t := after.In(location).Truncate(time.Minute).Add(time.Minute)
+
+	for i := 0; i < cronSearchLimit; i++ {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location))
+			continue
+		}
+		if !c.matchDay(t) {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location))
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		result := t.UTC()
+		return &result
+	}
+	return nil
+}
+
+// cronAdvance moves to the candidate time. The local times skipped by a daylight saving time change are normalized backwards,
+// so it moves to the next hour instead if the candidate is not after the current time.
+func cronAdvance(current time.Time, candidate time.Time) time.Time {
+	if candidate.After(current) {
+		return candidate
+	}
+	return current.Add(time.Duration(60-current.Minute()) * time.Minute)
+}
+
+func (c CronExpression) matchDay(t time.Time) bool {
+	dayOfMonth := c.daysOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := c.daysOfWeek&(1<<uint(t.Weekday())) != 0
+
+	if c.daysOfMonthAny || c.daysOfWeekAny {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+	var result uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart := part
+		step := 1
+		if index := strings.Index(part, "/"); index >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[index+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid %s step in %s", field.name, value)
+			}
+			rangePart = part[:index]
+		}
+
+		start, end := field.min, field.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			start, err = parseCronValue(bounds[0], field)
+			if err != nil {
+				return 0, err
+			}
+			end, err = parseCronValue(bounds[1], field)
+			if err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid %s range in %s", field.name, value)
+			}
+		default:
+			var err error
+			start, err = parseCronValue(rangePart, field)
+			if err != nil {
+				return 0, err
+			}
+			if step == 1 {
+				end = start //a single value, otherwise it is a step from the value to the end
+			}
+		}
+
+		for i := start; i <= end; i += step {
+			result |= 1 << uint(i)
+		}
+	}
+	return result, nil
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < field.min || parsed > field.max {
+		return 0, fmt.Errorf("invalid %s value %s, %d-%d expected", field.name, value, field.min, field.max)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// QueueDeadLetter represents a queue item which could not be delivered - it has used up its retries or it has hit a permanent error
+type QueueDeadLetter struct {
+	OrgID string `json:"org_id" bson:"org_id"`
+	AppID string `json:"app_id" bson:"app_id"`
+	ID    string `json:"id" bson:"_id"`
+
+	QueueItemID        string `json:"queue_item_id" bson:"queue_item_id"`
+	MessageID          string `json:"message_id" bson:"message_id"`
+	MessageRecipientID string `json:"message_recipient_id" bson:"message_recipient_id"`
+	UserID             string `json:"user_id" bson:"user_id"`
+
+	Subject  string            `json:"subject" bson:"subject"`
+	Body     string            `json:"body" bson:"body"`
+	Data     map[string]string `json:"data" bson:"data"`
+	Priority int               `json:"priority" bson:"priority"`
+
+	Localizations map[string]MessageLocalization `json:"localizations" bson:"localizations,omitempty"`
+
+	ExpiresAt   *time.Time   `json:"expires_at" bson:"expires_at,omitempty"`
+	CollapseKey *string      `json:"collapse_key" bson:"collapse_key,omitempty"`
+	Topic       *string      `json:"topic" bson:"topic,omitempty"`
+	DigestOf    []string     `json:"digest_of" bson:"digest_of,omitempty"`
+	PushOptions *PushOptions `json:"push_options" bson:"push_options,omitempty"`
+	Silent      bool         `json:"silent" bson:"silent,omitempty"`
+
+	Tokens    []string `json:"tokens" bson:"tokens"` //the tokens the item has failed for
+	Attempts  int      `json:"attempts" bson:"attempts"`
+	ErrorCode *string  `json:"error_code" bson:"error_code"` //the last error
+	Error     string   `json:"error" bson:"error"`
+
+	DateCreated time.Time `json:"date_created" bson:"date_created"`
+} //@name QueueDeadLetter
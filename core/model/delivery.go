@@ -0,0 +1,64 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+const (
+	//DeliveryStatusSent the notification has been accepted by FCM
+	DeliveryStatusSent string = "sent"
+	//DeliveryStatusFailed the notification has been rejected by FCM
+	DeliveryStatusFailed string = "failed"
+)
+
+// DeliveryAttempt represents a single attempt for delivering a queue item to a firebase token
+type DeliveryAttempt struct {
+	OrgID string `json:"org_id" bson:"org_id"`
+	AppID string `json:"app_id" bson:"app_id"`
+	ID    string `json:"id" bson:"_id"`
+
+	QueueItemID        string `json:"queue_item_id" bson:"queue_item_id"`
+	MessageID          string `json:"message_id" bson:"message_id"`
+	MessageRecipientID string `json:"message_recipient_id" bson:"message_recipient_id"`
+	UserID             string `json:"user_id" bson:"user_id"`
+
+	Token       string  `json:"token" bson:"token"`
+	AppPlatform *string `json:"app_platform" bson:"app_platform"`
+
+	Status       string  `json:"status" bson:"status"` // sent or failed
+	FCMMessageID *string `json:"fcm_message_id" bson:"fcm_message_id"`
+	ErrorCode    *string `json:"error_code" bson:"error_code"`
+	Error        *string `json:"error" bson:"error"`
+
+	DateCreated time.Time `json:"date_created" bson:"date_created"`
+} //@name DeliveryAttempt
+
+// TokenPrune represents a firebase token removed from an user because FCM has reported it as invalid
+type TokenPrune struct {
+	OrgID string `json:"org_id" bson:"org_id"`
+	AppID string `json:"app_id" bson:"app_id"`
+	ID    string `json:"id" bson:"_id"`
+
+	UserID      string   `json:"user_id" bson:"user_id"`
+	Token       string   `json:"token" bson:"token"`
+	AppPlatform *string  `json:"app_platform" bson:"app_platform"`
+	Topics      []string `json:"topics" bson:"topics"` //the topics the token has been unsubscribed from
+
+	QueueItemID string `json:"queue_item_id" bson:"queue_item_id"` //the queue item which has detected the invalid token
+	MessageID   string `json:"message_id" bson:"message_id"`
+	ErrorCode   string `json:"error_code" bson:"error_code"`
+
+	DateCreated time.Time `json:"date_created" bson:"date_created"`
+} //@name TokenPrune
@@ -14,10 +14,125 @@
 
 package model
 
+import (
+	"fmt"
+	"time"
+)
+
 // FirebaseConf represents the firebase configuration for org/app pair.
 type FirebaseConf struct {
 	OrgID     string `bson:"org_id"`
 	AppID     string `bson:"app_id"`
 	ProjectID string `bson:"project_id"`
 	Auth      string `bson:"auth"`
+
+	RateLimit *FirebaseRateLimit `bson:"rate_limit,omitempty"`
+}
+
+// FirebaseRateLimit represents the delivery rate limit for org/app pair. The items over the limit are deferred.
+type FirebaseRateLimit struct {
+	Rate  int `bson:"rate"`  //messages per second
+	Burst int `bson:"burst"` //max messages at once, the rate is used if not set
+}
+
+// FirebaseMaxBatchSize is the max number of messages which can be sent in one batch
+const FirebaseMaxBatchSize int = 500
+
+// FirebaseMessage represents a notification to be sent to a firebase token
+type FirebaseMessage struct {
+	Token       string
+	Title       string
+	Body        string
+	Data        map[string]string
+	Priority    int        //see Message.Priority
+	ExpiresAt   *time.Time //FCM does not try to deliver the message after that
+	CollapseKey *string    //the device replaces the earlier notification with the same key
+	PushOptions *PushOptions
+	Silent      bool //data-only, it wakes the app without alerting the user
+	Badge       *int //the iOS app icon badge - the user unread messages count, the push options badge overrides it
+}
+
+// FirebaseSendResult represents the result of sending a notification to a firebase token
+type FirebaseSendResult struct {
+	Token     string
+	MessageID string //FCM message id, set on success
+	Err       error  //*FirebaseSendError when FCM has rejected the message
+}
+
+const (
+	//FirebaseErrorTokenNotRegistered the token is not valid anymore
+	FirebaseErrorTokenNotRegistered string = "registration-token-not-registered"
+	//FirebaseErrorInvalidArgument the request contains invalid data - the payload (over 4KB, invalid field etc) or the token, so it does not say that the token is invalid
+	FirebaseErrorInvalidArgument string = "invalid-argument"
+	//FirebaseErrorMessageRateExceeded the sending rate is too high
+	FirebaseErrorMessageRateExceeded string = "message-rate-exceeded"
+	//FirebaseErrorServerUnavailable FCM is temporary unavailable
+	FirebaseErrorServerUnavailable string = "server-unavailable"
+	//FirebaseErrorInternal FCM internal error
+	FirebaseErrorInternal string = "internal-error"
+	//FirebaseErrorMismatchedCredential the token belongs to another sender
+	FirebaseErrorMismatchedCredential string = "mismatched-credential"
+	//FirebaseErrorInvalidAPNSCredentials the APNs credentials are not valid
+	FirebaseErrorInvalidAPNSCredentials string = "invalid-apns-credentials"
+	//FirebaseErrorUnknown FCM unknown error
+	FirebaseErrorUnknown string = "unknown-error"
+)
+
+// FirebaseSendError wraps an error returned by FCM while sending a notification
+type FirebaseSendError struct {
+	Code  string //FCM error code, empty if unknown
+	Token string
+	Err   error
+}
+
+func (e *FirebaseSendError) Error() string {
+	return fmt.Sprintf("error while sending notification to token (%s): %s", e.Token, e.Err)
+}
+
+// Unwrap gives the original error
+func (e *FirebaseSendError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransient says if the failed send could succeed if retried later
+func (e *FirebaseSendError) IsTransient() bool {
+	switch e.Code {
+	case FirebaseErrorMessageRateExceeded, FirebaseErrorServerUnavailable, FirebaseErrorInternal, FirebaseErrorUnknown:
+		return true
+	case "":
+		return true //not recognized by FCM - network failures, timeouts etc
+	default:
+		return false
+	}
+}
+
+// IsInvalidToken says if the token is not valid anymore and it should be removed
+func (e *FirebaseSendError) IsInvalidToken() bool {
+	return e.Code == FirebaseErrorTokenNotRegistered
+}
+
+// FirebaseClientNotFoundError is given when there is no firebase client for the org/app pair, i.e. it has no valid firebase configuration
+type FirebaseClientNotFoundError struct {
+	OrgID string
+	AppID string
+}
+
+func (e *FirebaseClientNotFoundError) Error() string {
+	return fmt.Sprintf("there is no firebase client for org (%s) app (%s)", e.OrgID, e.AppID)
+}
+
+// FirebaseConfError wraps an error while creating the firebase client for the org/app pair configuration
+type FirebaseConfError struct {
+	OrgID string
+	AppID string
+	Err   error
+}
+
+func (e *FirebaseConfError) Error() string {
+	return fmt.Sprintf("error while creating firebase client for org (%s) app (%s): %s", e.OrgID, e.AppID, e.Err)
+}
+
+// Unwrap gives the original error
+func (e *FirebaseConfError) Unwrap() error {
+	return e.Err
 }
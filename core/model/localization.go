@@ -0,0 +1,77 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "strings"
+
+// MessageLocalization is the message content in one language
+type MessageLocalization struct {
+	Subject string `json:"subject" bson:"subject"`
+	Body    string `json:"body" bson:"body"`
+} //@name MessageLocalization
+
+// GetLocalizedContent gives the subject and the body in the best match for the preferred locales which are in order of preference.
+// For every preferred locale it tries the same locale, then its language and then another locale of the same language, for example
+// es-MX, es and es-ES. The default subject and body are given if none of the preferred locales matches.
+func GetLocalizedContent(localizations map[string]MessageLocalization, subject string, body string, locales []string) (string, string) {
+	if len(localizations) == 0 {
+		return subject, body
+	}
+
+	for _, locale := range locales {
+		localization := findLocalization(localizations, normalizeLocale(locale))
+		if localization != nil {
+			return localization.Subject, localization.Body
+		}
+	}
+	return subject, body
+}
+
+func findLocalization(localizations map[string]MessageLocalization, locale string) *MessageLocalization {
+	if len(locale) == 0 {
+		return nil
+	}
+	language := getLocaleLanguage(locale)
+
+	var languageMatch *MessageLocalization
+	var sameLanguageMatch *MessageLocalization
+	sameLanguageKey := ""
+	for key, current := range localizations {
+		key = normalizeLocale(key)
+		switch {
+		case key == locale:
+			return &current
+		case key == language:
+			languageMatch = &current
+		case getLocaleLanguage(key) == language && (sameLanguageMatch == nil || key < sameLanguageKey):
+			sameLanguageMatch = &current //the first one in alphabetical order so that it is the same every time
+			sameLanguageKey = key
+		}
+	}
+	if languageMatch != nil {
+		return languageMatch
+	}
+	return sameLanguageMatch
+}
+
+// normalizeLocale gives the locale in lower case with "-" as separator, for example es_MX becomes es-mx
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+func getLocaleLanguage(locale string) string {
+	language, _, _ := strings.Cut(locale, "-")
+	return language
+}
@@ -18,6 +18,36 @@ import (
 	"time"
 )
 
+// MessageCollapseKeyMaxLength is the max length of the collapse key, it is limited by APNs
+const MessageCollapseKeyMaxLength int = 64
+
+// PushOptionsNameMaxLength is the max length of the sound, the Android channel id and the APNs category names
+const PushOptionsNameMaxLength int = 256
+
+// MessagePriorityDefault is the priority of the messages which do not specify one.
+// Higher values are more urgent - they are delivered first and sent as high priority pushes,
+// lower values are delivered after them and sent as normal(power saving) pushes.
+const MessagePriorityDefault int = 0
+
+const (
+	//MessageDeliveryModeAlert the message is pushed as a visible notification and shown in the inbox, it is the default
+	MessageDeliveryModeAlert string = "alert"
+	//MessageDeliveryModeSilent the message is pushed as a data-only notification which wakes the app without alerting the user
+	MessageDeliveryModeSilent string = "silent"
+	//MessageDeliveryModeInboxOnly the message is shown in the inbox only, no push notification is sent
+	MessageDeliveryModeInboxOnly string = "inbox_only"
+)
+
+// IsValidMessageDeliveryMode checks if the delivery mode is supported, empty means the default one
+func IsValidMessageDeliveryMode(deliveryMode string) bool {
+	switch deliveryMode {
+	case "", MessageDeliveryModeAlert, MessageDeliveryModeSilent, MessageDeliveryModeInboxOnly:
+		return true
+	default:
+		return false
+	}
+}
+
 // InputMessage represents the data structure needed for creating a message. It is the input data for the core module.
 type InputMessage struct {
 	OrgID string
@@ -27,6 +57,7 @@ type InputMessage struct {
 
 	Sender                   Sender
 	Time                     time.Time
+	ExpiresAt                *time.Time
 	Priority                 int
 	Subject                  string
 	Body                     string
@@ -35,6 +66,16 @@ type InputMessage struct {
 	RecipientsCriteriaList   []RecipientCriteria
 	RecipientAccountCriteria map[string]interface{}
 	Topic                    *string
+
+	CollapseKey       *string //replaces the earlier notifications with the same key
+	SupersedePrevious bool    //marks the earlier inbox entries with the same collapse key as superseded
+
+	PushOptions *PushOptions
+
+	DeliveryMode  string //alert, silent or inbox_only, alert if not set
+	SilentInInbox bool   //writes the silent message in the users inboxes
+
+	Localizations map[string]MessageLocalization //locale -> the subject and the body in the locale language
 }
 
 // InputMessageRecipient represents the data structure needed for creating a message recipient. It is the input data for the core module.
@@ -50,13 +91,16 @@ type Message struct {
 	OrgID string `json:"org_id" bson:"org_id"`
 	AppID string `json:"app_id" bson:"app_id"`
 
-	ID       string            `json:"id" bson:"_id"`
-	Time     time.Time         `json:"time" bson:"time"`
-	Priority int               `json:"priority" bson:"priority"`
-	Subject  string            `json:"subject" bson:"subject"`
-	Sender   Sender            `json:"sender,omitempty" bson:"sender,omitempty"`
-	Body     string            `json:"body" bson:"body"`
-	Data     map[string]string `json:"data" bson:"data"`
+	ID        string            `json:"id" bson:"_id"`
+	Time      time.Time         `json:"time" bson:"time"`
+	ExpiresAt *time.Time        `json:"expires_at" bson:"expires_at,omitempty"` //it is not delivered and not shown in the inbox after that
+	Priority  int               `json:"priority" bson:"priority"`
+	Subject   string            `json:"subject" bson:"subject"`
+	Sender    Sender            `json:"sender,omitempty" bson:"sender,omitempty"`
+	Body      string            `json:"body" bson:"body"`
+	Data      map[string]string `json:"data" bson:"data"`
+
+	Localizations map[string]MessageLocalization `json:"localizations" bson:"localizations,omitempty"` //locale -> the subject and the body in the locale language
 
 	//recipients related
 	Recipients               []MessageRecipient     `json:"recipients" bson:"recipients"` //keep it for back compatability
@@ -64,6 +108,13 @@ type Message struct {
 	RecipientAccountCriteria map[string]interface{} `json:"recipient_account_criteria" bson:"recipient_account_criteria"`
 	Topic                    *string                `json:"topic" bson:"topic"`
 
+	CollapseKey *string `json:"collapse_key" bson:"collapse_key,omitempty"` //the devices replace the earlier notifications with the same key
+
+	PushOptions *PushOptions `json:"push_options" bson:"push_options,omitempty"`
+
+	DeliveryMode  string `json:"delivery_mode" bson:"delivery_mode,omitempty"`     //alert if not set
+	SilentInInbox bool   `json:"silent_in_inbox" bson:"silent_in_inbox,omitempty"` //the silent message is written in the users inboxes
+
 	//initialy calculated recipients count
 	//if nil then it means that the message was created before the refactoring
 	CalculatedRecipientsCount *int `json:"calculated_recipients_count" bson:"calculated_recipients_count"`
@@ -80,6 +131,55 @@ func (m *Message) IsSender(userID string) bool {
 	return false
 }
 
+// GetLocalizedContent gives the subject and the body in the best match for the preferred locales
+func (m *Message) GetLocalizedContent(locales []string) (string, string) {
+	return GetLocalizedContent(m.Localizations, m.Subject, m.Body, locales)
+}
+
+// IsSilent checks if the message is pushed as a data-only notification
+func (m *Message) IsSilent() bool {
+	return m.DeliveryMode == MessageDeliveryModeSilent
+}
+
+// IsPushed checks if push notifications are sent for the message
+func (m *Message) IsPushed() bool {
+	return m.DeliveryMode != MessageDeliveryModeInboxOnly
+}
+
+// IsInInbox checks if the message is written in the users inboxes
+func (m *Message) IsInInbox() bool {
+	return !m.IsSilent() || m.SilentInInbox
+}
+
+// IsPending checks if the message has not been sent yet - it is scheduled for a later time
+func (m *Message) IsPending(now time.Time) bool {
+	return m.Time.After(now)
+}
+
+// PushOptions represents how the push notification is presented on the different platforms
+type PushOptions struct {
+	Sound            *string `json:"sound" bson:"sound,omitempty"` //the sound file name in the app, "default" for the system sound
+	Badge            *int    `json:"badge" bson:"badge,omitempty"` //the iOS app icon badge, 0 removes it
+	ImageURL         *string `json:"image_url" bson:"image_url,omitempty"`
+	AndroidChannelID *string `json:"android_channel_id" bson:"android_channel_id,omitempty"`
+	APNSCategory     *string `json:"apns_category" bson:"apns_category,omitempty"` //the iOS category with the notification actions
+	WebpushLink      *string `json:"webpush_link" bson:"webpush_link,omitempty"`   //opened when the web notification is clicked
+} //@name PushOptions
+
+// MessageCancellation is the audit record of a scheduled message which has been cancelled before it has been sent
+type MessageCancellation struct {
+	OrgID string `json:"org_id" bson:"org_id"`
+	AppID string `json:"app_id" bson:"app_id"`
+	ID    string `json:"id" bson:"_id"`
+
+	MessageID       string  `json:"message_id" bson:"message_id"`
+	Message         Message `json:"message" bson:"message"`                     //the message as it was when cancelled
+	QueueItemsCount int     `json:"queue_items_count" bson:"queue_items_count"` //the removed notifications
+	CancelledBy     Sender  `json:"cancelled_by" bson:"cancelled_by"`
+
+	DateCreated time.Time `json:"date_created" bson:"date_created"`
+} //@name MessageCancellation
+
 // Sender is a system generated fingerprint for the originator of the message. It may be a user from the admin app or an external system
 // @name Sender
 // @ID Sender
@@ -107,3 +207,11 @@ type MessagesStats struct {
 	Unread       *int64 `json:"not_read_count" bson:"not_read_count"`
 	UnreadUnmute *int64 `json:"not_read_not_mute" bson:"not_read_not_mute"`
 }
+
+// UserUnreadCount is the count of the user unread, not muted and not superseded messages which are shown in the inbox. It is shown as the iOS app icon badge.
+type UserUnreadCount struct {
+	OrgID  string `bson:"org_id"`
+	AppID  string `bson:"app_id"`
+	UserID string `bson:"user_id"`
+	Count  int    `bson:"count"`
+}
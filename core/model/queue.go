@@ -14,13 +14,57 @@
 
 package model
 
-import "time"
+import (
+	"hash/fnv"
+	"time"
+)
+
+// QueueSlotsCount is the number of slots the queue items are spread in. Partition p of n processes the slots for which slot % n == p.
+const QueueSlotsCount int = 1024
+
+// GetQueueSlot gives the slot of the user queue items
+func GetQueueSlot(userID string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(userID))
+	return int(hash.Sum32() % uint32(QueueSlotsCount))
+}
+
+const (
+	//QueueStatusReady the queue is free for processing
+	QueueStatusReady string = "ready"
+	//QueueStatusProcessing the queue is being processed by the lease owner
+	QueueStatusProcessing string = "processing"
+)
 
 // Queue represent queue status entity
 type Queue struct {
-	ID                string `bson:"_id"`
-	Status            string `bson:"status"`
-	ProcessItemsCount int    `bson:"process_items_count"`
+	ID                string `json:"id" bson:"_id"`
+	Partition         int    `json:"partition" bson:"partition"`
+	Status            string `json:"status" bson:"status"`
+	ProcessItemsCount int    `json:"process_items_count" bson:"process_items_count"`
+
+	//lease
+	LeaseOwner     string     `json:"lease_owner" bson:"lease_owner"`           //the instance which processes the queue
+	LeaseExpiresAt *time.Time `json:"lease_expires_at" bson:"lease_expires_at"` //another instance can take over the queue after it
+	LeaseRenewedAt *time.Time `json:"lease_renewed_at" bson:"lease_renewed_at"`
+} //@name Queue
+
+// IsLeaseExpired says if the queue is locked by an instance which has stopped renewing its lease
+func (q Queue) IsLeaseExpired(now time.Time) bool {
+	if q.Status != QueueStatusProcessing {
+		return false
+	}
+	return q.LeaseExpiresAt == nil || q.LeaseExpiresAt.Before(now) //no expiry means locked before the leases were introduced
+}
+
+// QueueConfig represents the queue processing configuration
+type QueueConfig struct {
+	WorkersCount          int //how many items are sent in parallel
+	BufferSize            int //how many items can wait for a free worker
+	BackpressureThreshold int //the next items are not loaded until the in-flight items drop below it
+	PartitionsCount       int //the queue partitions, they can be processed by different instances in parallel
+
+	QuietHoursBypassPriority int //the messages with higher priority are sent in the users quiet hours
 }
 
 // QueueItem represent notifications queue data item
@@ -41,7 +85,75 @@ type QueueItem struct {
 	Body    string            `bson:"body"`
 	Data    map[string]string `bson:"data"`
 
+	Localizations map[string]MessageLocalization `bson:"localizations,omitempty"` //locale -> the subject and the body in the locale language
+
 	//when to send
-	Time     time.Time `bson:"time"`
-	Priority int       `bson:"priority"`
+	Time      time.Time  `bson:"time"`
+	Priority  int        `bson:"priority"`
+	ExpiresAt *time.Time `bson:"expires_at,omitempty"` //the item is dropped if it has not been sent until then
+
+	CollapseKey *string      `bson:"collapse_key,omitempty"` //the item is removed when a newer message with the same key comes
+	Topic       *string      `bson:"topic,omitempty"`
+	PushOptions *PushOptions `bson:"push_options,omitempty"`
+	Silent      bool         `bson:"silent,omitempty"` //data-only notification which wakes the app without alerting the user
+
+	//digest
+	Digest   bool     `bson:"digest,omitempty"`    //collected for the user digest, it is bundled in the summary when due
+	DigestOf []string `bson:"digest_of,omitempty"` //the item is the summary of these messages
+
+	//partitioning
+	Slot int `bson:"slot"` //see GetQueueSlot
+
+	//retries
+	Attempts int      `bson:"attempts"`         //how many times the item has been retried
+	Tokens   []string `bson:"tokens,omitempty"` //when set, send only to these tokens (the ones failed on the previous attempt)
+}
+
+// GetLocalizedContent gives the subject and the body in the best match for the token locale
+func (q QueueItem) GetLocalizedContent(locale *string) (string, string) {
+	if locale == nil {
+		return q.Subject, q.Body
+	}
+	return GetLocalizedContent(q.Localizations, q.Subject, q.Body, []string{*locale})
+}
+
+// IsExpired checks if the item must not be sent anymore
+func (q QueueItem) IsExpired(now time.Time) bool {
+	return q.ExpiresAt != nil && !now.Before(*q.ExpiresAt)
 }
+
+// QueuePause represents paused queue processing for an org/app - its items stay in the queue until the processing is resumed
+type QueuePause struct {
+	OrgID string `json:"org_id" bson:"org_id"`
+	AppID string `json:"app_id" bson:"app_id"`
+	ID    string `json:"id" bson:"_id"`
+
+	PausedBy    string    `json:"paused_by" bson:"paused_by"` //the account which has paused the processing
+	DateCreated time.Time `json:"date_created" bson:"date_created"`
+} //@name QueuePause
+
+// QueueState represents the state of the whole queue
+type QueueState struct {
+	Partitions []Queue    `json:"partitions"`
+	NextTime   *time.Time `json:"next_time"` //the time of the first upcoming item in the whole queue, the queue is processed then
+} //@name QueueState
+
+// QueueOverview represents the queue state for an org/app
+type QueueOverview struct {
+	Pause   *QueuePause  `json:"pause"` //nil when the org/app items are processed
+	Pending QueuePending `json:"pending"`
+} //@name QueueOverview
+
+// QueuePending represents the pending queue items for an org/app
+type QueuePending struct {
+	Count    int64                 `json:"count"`
+	NextTime *time.Time            `json:"next_time"`
+	Messages []QueueMessagePending `json:"messages"`
+} //@name QueuePending
+
+// QueueMessagePending represents the pending queue items for a message
+type QueueMessagePending struct {
+	MessageID string    `json:"message_id" bson:"_id"`
+	Count     int64     `json:"count" bson:"count"`
+	NextTime  time.Time `json:"next_time" bson:"next_time"`
+} //@name QueueMessagePending
@@ -27,6 +27,8 @@ type MessageRecipient struct {
 	Mute      bool   `json:"mute" bson:"mute"`
 	Read      bool   `json:"read" bson:"read"`
 
+	Superseded bool `json:"superseded" bson:"superseded,omitempty"` //there is a newer message with the same collapse key
+
 	Message Message `json:"-" bson:"-"`
 
 	DateCreated *time.Time `json:"date_created" bson:"date_created"`
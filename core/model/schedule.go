@@ -0,0 +1,112 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	//MessageScheduleStatusActive the schedule creates messages on its runs
+	MessageScheduleStatusActive string = "active"
+	//MessageScheduleStatusPaused the schedule does not create messages until it is resumed
+	MessageScheduleStatusPaused string = "paused"
+)
+
+// MessageSchedule represents a recurring message. A new message and its recipients are created from the template on every run.
+type MessageSchedule struct {
+	OrgID string `json:"org_id" bson:"org_id"`
+	AppID string `json:"app_id" bson:"app_id"`
+
+	ID       string          `json:"id" bson:"_id"`
+	Cron     string          `json:"cron" bson:"cron"`           //minute hour day-of-month month day-of-week
+	TimeZone string          `json:"time_zone" bson:"time_zone"` //IANA time zone in which the cron expression is evaluated
+	Status   string          `json:"status" bson:"status"`
+	Message  MessageTemplate `json:"message" bson:"message"`
+
+	NextRunAt     *time.Time `json:"next_run_at" bson:"next_run_at"` //nil when paused
+	LastRunAt     *time.Time `json:"last_run_at" bson:"last_run_at"`
+	LastMessageID *string    `json:"last_message_id" bson:"last_message_id"`
+	RunsCount     int        `json:"runs_count" bson:"runs_count"`
+
+	DateCreated time.Time  `json:"date_created" bson:"date_created"`
+	DateUpdated *time.Time `json:"date_updated" bson:"date_updated"`
+} //@name MessageSchedule
+
+// GetNextRun gives the first run of the schedule after the given time
+func (s *MessageSchedule) GetNextRun(after time.Time) (*time.Time, error) {
+	expression, err := ParseCronExpression(s.Cron)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %s", s.TimeZone)
+	}
+
+	nextRun := expression.Next(after, location)
+	if nextRun == nil {
+		return nil, fmt.Errorf("cron expression %s never runs", s.Cron)
+	}
+	return nextRun, nil
+}
+
+// IsSender checks if the user is the sender of the schedule messages
+func (s *MessageSchedule) IsSender(userID string) bool {
+	return s.Message.Sender.User != nil && s.Message.Sender.User.UserID == userID
+}
+
+// MessageTemplate is the message created on every schedule run
+type MessageTemplate struct {
+	Sender                   Sender                 `json:"sender" bson:"sender"`
+	Priority                 int                    `json:"priority" bson:"priority"`
+	Subject                  string                 `json:"subject" bson:"subject"`
+	Body                     string                 `json:"body" bson:"body"`
+	Data                     map[string]string      `json:"data" bson:"data"`
+	Recipients               []MessageRecipient     `json:"recipients" bson:"recipients"`
+	RecipientsCriteriaList   []RecipientCriteria    `json:"recipients_criteria_list" bson:"recipients_criteria_list"`
+	RecipientAccountCriteria map[string]interface{} `json:"recipient_account_criteria" bson:"recipient_account_criteria"`
+	Topic                    *string                `json:"topic" bson:"topic"`
+	CollapseKey              *string                `json:"collapse_key" bson:"collapse_key,omitempty"`
+	SupersedePrevious        bool                   `json:"supersede_previous" bson:"supersede_previous"`
+	TTL                      *int                   `json:"ttl" bson:"ttl,omitempty"` //seconds after the run when the message expires
+	PushOptions              *PushOptions           `json:"push_options" bson:"push_options,omitempty"`
+	DeliveryMode             string                 `json:"delivery_mode" bson:"delivery_mode,omitempty"`
+	SilentInInbox            bool                   `json:"silent_in_inbox" bson:"silent_in_inbox,omitempty"`
+
+	Localizations map[string]MessageLocalization `json:"localizations" bson:"localizations,omitempty"`
+}
+
+// NewInputMessage creates the input for the message of a schedule run
+func (t MessageTemplate) NewInputMessage(orgID string, appID string, messageID string, runAt time.Time) InputMessage {
+	var expiresAt *time.Time
+	if t.TTL != nil {
+		value := runAt.Add(time.Duration(*t.TTL) * time.Second)
+		expiresAt = &value
+	}
+
+	//every message gets its own data as the message id is added to it
+	data := make(map[string]string, len(t.Data))
+	for key, value := range t.Data {
+		data[key] = value
+	}
+
+	return InputMessage{OrgID: orgID, AppID: appID, ID: &messageID, Sender: t.Sender, Time: runAt, ExpiresAt: expiresAt,
+		Priority: t.Priority, Subject: t.Subject, Body: t.Body, Data: data, InputRecipients: t.Recipients,
+		RecipientsCriteriaList: t.RecipientsCriteriaList, RecipientAccountCriteria: t.RecipientAccountCriteria,
+		Topic: t.Topic, CollapseKey: t.CollapseKey, SupersedePrevious: t.SupersedePrevious, PushOptions: t.PushOptions,
+		DeliveryMode: t.DeliveryMode, SilentInInbox: t.SilentInInbox, Localizations: t.Localizations}
+}
@@ -21,6 +21,7 @@ type FirebaseToken struct {
 	Token       string     `json:"token" bson:"token"`
 	AppPlatform *string    `json:"app_platform" bson:"app_platform"`
 	AppVersion  *string    `json:"app_version" bson:"app_version"`
+	Locale      *string    `json:"locale" bson:"locale,omitempty"` //the device locale, for example es-MX
 	DateCreated time.Time  `json:"date_created" bson:"date_created"`
 	DateUpdated *time.Time `json:"date_updated" bson:"date_updated"`
 } // @name FirebaseToken
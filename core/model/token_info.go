@@ -20,4 +20,5 @@ type TokenInfo struct {
 	Token         *string `json:"token" bson:"token"`
 	AppVersion    *string `json:"app_version" bson:"app_version"`
 	AppPlatform   *string `json:"app_platform" bson:"app_platform"`
+	Locale        *string `json:"locale" bson:"locale"`
 } // @name TokenInfo
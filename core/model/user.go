@@ -14,7 +14,10 @@
 
 package model
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // User represents user entity and all its relationship with firebase tokens and topics
 type User struct {
@@ -26,10 +29,94 @@ type User struct {
 	FirebaseTokens        []FirebaseToken `json:"firebase_tokens" bson:"firebase_tokens"`
 	UserID                string          `json:"user_id" bson:"user_id"`
 	Topics                []string        `json:"topics" bson:"topics"`
+	QuietHours            *QuietHours     `json:"quiet_hours" bson:"quiet_hours,omitempty"`
+	TimeZone              *string         `json:"time_zone" bson:"time_zone,omitempty"` //IANA time zone, UTC is used if not set
+	Digest                *DigestSettings `json:"digest" bson:"digest,omitempty"`
 	DateCreated           time.Time       `json:"date_created" bson:"date_created"`
 	DateUpdated           time.Time       `json:"date_updated" bson:"date_updated"`
 } //@name User
 
+// GetLocation gives the user time zone location
+func (t *User) GetLocation() *time.Location {
+	if t.TimeZone == nil {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(*t.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// GetQuietHoursEnd gives the end of the user quiet hours if the time is in them, otherwise nil
+func (t *User) GetQuietHoursEnd(now time.Time) *time.Time {
+	if t.QuietHours == nil || t.QuietHours.IsEmpty() {
+		return nil
+	}
+	start, err := parseDayTime(t.QuietHours.Start)
+	if err != nil {
+		return nil
+	}
+	end, err := parseDayTime(t.QuietHours.End)
+	if err != nil {
+		return nil
+	}
+
+	localNow := now.In(t.GetLocation())
+	current := localNow.Hour()*60 + localNow.Minute()
+	endToday := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), end/60, end%60, 0, 0, localNow.Location())
+
+	var quietEnd time.Time
+	switch {
+	case start < end && current >= start && current < end:
+		quietEnd = endToday //the window is in the same day
+	case start > end && current >= start:
+		quietEnd = endToday.AddDate(0, 0, 1) //the window ends the next day
+	case start > end && current < end:
+		quietEnd = endToday //the window has started the previous day
+	default:
+		return nil
+	}
+	quietEnd = quietEnd.UTC()
+	return &quietEnd
+}
+
+// IsDigestItem checks if the queue item has to be bundled in the user digest
+func (t *User) IsDigestItem(item QueueItem) bool {
+	if t.Digest == nil || t.Digest.IsEmpty() {
+		return false
+	}
+	if len(item.DigestOf) > 0 || item.Priority > MessagePriorityDefault || item.Silent {
+		return false //the digest itself, the urgent and the silent items are sent as they are
+	}
+	if len(t.Digest.Topics) == 0 {
+		return true
+	}
+	if item.Topic == nil {
+		return false
+	}
+	for _, topic := range t.Digest.Topics {
+		if topic == *item.Topic {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDigestWindowEnd gives the end of the current digest window. The windows start from the midnight in the user time zone.
+func (t *User) GetDigestWindowEnd(now time.Time) time.Time {
+	localNow := now.In(t.GetLocation())
+	dayStart := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), 0, 0, 0, 0, localNow.Location())
+	nextDayStart := dayStart.AddDate(0, 0, 1)
+
+	interval := time.Duration(t.Digest.Interval) * time.Minute
+	windowEnd := dayStart.Add((localNow.Sub(dayStart)/interval + 1) * interval)
+	if windowEnd.After(nextDayStart) {
+		windowEnd = nextDayStart
+	}
+	return windowEnd.UTC()
+}
+
 // AddToken adds topic to the list
 func (t *User) AddToken(token string) {
 	if t.FirebaseTokens == nil {
@@ -75,6 +162,67 @@ func (t *User) HasTopic(topic string) bool {
 	return exists
 }
 
+// QuietHours represents a daily window in the user time zone in which the user does not receive notifications
+type QuietHours struct {
+	Start string `json:"start" bson:"start"` //HH:MM
+	End   string `json:"end" bson:"end"`     //HH:MM, it is on the next day if it is before the start
+} //@name QuietHours
+
+// IsEmpty checks if the quiet hours are not set
+func (q QuietHours) IsEmpty() bool {
+	return len(q.Start) == 0 && len(q.End) == 0
+}
+
+// Validate checks if the quiet hours are valid
+func (q QuietHours) Validate() error {
+	if q.IsEmpty() {
+		return nil
+	}
+	start, err := parseDayTime(q.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseDayTime(q.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return fmt.Errorf("quiet hours start and end are the same - %s", q.Start)
+	}
+	return nil
+}
+
+// DigestMaxInterval is the max digest interval in minutes
+const DigestMaxInterval int = 24 * 60
+
+// DigestSettings represents the user preference to get the low priority notifications bundled in one summary notification
+type DigestSettings struct {
+	Interval int      `json:"interval" bson:"interval"` //minutes, the notifications are bundled for that long
+	Topics   []string `json:"topics" bson:"topics"`     //only the notifications for these topics are bundled, all if empty
+} //@name DigestSettings
+
+// IsEmpty checks if the digest is not set
+func (d DigestSettings) IsEmpty() bool {
+	return d.Interval == 0
+}
+
+// Validate checks if the digest settings are valid
+func (d DigestSettings) Validate() error {
+	if d.Interval < 0 || d.Interval > DigestMaxInterval {
+		return fmt.Errorf("invalid digest interval %d, it must be between 0 and %d minutes", d.Interval, DigestMaxInterval)
+	}
+	return nil
+}
+
+// parseDayTime gives the minutes from the day start for HH:MM
+func parseDayTime(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid day time %s, HH:MM expected", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
 //////////////////////////
 
 // CoreAccount represents an account in the Core BB
@@ -20,54 +20,48 @@ import (
 	"fmt"
 	"log"
 	"notifications/core/model"
+	"strconv"
+	"time"
 
-	firebase "firebase.google.com/go"
-	"firebase.google.com/go/messaging"
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/errorutils"
+	"firebase.google.com/go/v4/messaging"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
 )
 
 // Adapter entity
 type Adapter struct {
-	//key is org-id_app-id construction
-	firebaseClients map[string]firebase.App
+	clients *clientsRegistry
 }
 
 // NewFirebaseAdapter instance a new Firebase adapter
 func NewFirebaseAdapter() *Adapter {
-	return &Adapter{firebaseClients: make(map[string]firebase.App)}
+	return &Adapter{clients: newClientsRegistry(createFirebaseClient)}
 }
 
-// Start starts the firebase adapter
+// Start starts the firebase adapter. It fails only if there is no valid firebase configuration.
 func (fa *Adapter) Start(firebaseConfs []model.FirebaseConf) error {
-	return fa.setFirebaseClients(firebaseConfs)
-}
-
-// UpdateFirebaseConfigurations sets new firebase configurations
-func (fa *Adapter) UpdateFirebaseConfigurations(firebaseConfs []model.FirebaseConf) error {
-	return fa.setFirebaseClients(firebaseConfs)
-}
-
-func (fa *Adapter) setFirebaseClients(firebaseConfs []model.FirebaseConf) error {
-	//1. check if there are configs data
-	if len(firebaseConfs) == 0 {
-		return errors.New("there is no firebase configurations")
-	}
-
-	//2. create a firebase client for every configuration
-	for _, current := range firebaseConfs {
-		client, err := fa.createFirebaseClient(current)
+	err := fa.clients.reconcile(firebaseConfs)
+	if fa.clients.count() == 0 {
 		if err != nil {
 			return err
 		}
-
-		key := fmt.Sprintf("%s_%s", current.OrgID, current.AppID)
-		fa.firebaseClients[key] = *client
+		return errors.New("there is no firebase configurations")
+	}
+	if err != nil {
+		log.Printf("error while setting firebase configurations: %s", err)
 	}
 	return nil
 }
 
-func (fa *Adapter) createFirebaseClient(data model.FirebaseConf) (*firebase.App, error) {
+// UpdateFirebaseConfigurations reconciles the firebase clients with the new firebase configurations, an empty list removes all clients.
+// The error contains *model.FirebaseConfError for every org/app pair which has failed.
+func (fa *Adapter) UpdateFirebaseConfigurations(firebaseConfs []model.FirebaseConf) error {
+	return fa.clients.reconcile(firebaseConfs)
+}
+
+func createFirebaseClient(data model.FirebaseConf) (*firebase.App, error) {
 	conf, err := google.JWTConfigFromJSON([]byte(data.Auth),
 		"https://www.googleapis.com/auth/firebase",
 		"https://www.googleapis.com/auth/cloud-platform")
@@ -86,38 +80,254 @@ func (fa *Adapter) createFirebaseClient(data model.FirebaseConf) (*firebase.App,
 	return firebaseApp, nil
 }
 
-func (fa *Adapter) getFirebaseClient(orgID string, appID string) firebase.App {
-	key := fmt.Sprintf("%s_%s", orgID, appID)
-	return fa.firebaseClients[key]
+// getFirebaseClient gives the client for the org/app pair, *model.FirebaseClientNotFoundError if it has no firebase configuration
+func (fa *Adapter) getFirebaseClient(orgID string, appID string) (*firebase.App, error) {
+	return fa.clients.get(orgID, appID)
 }
 
-// SendNotificationToToken sends a notification to token
-func (fa *Adapter) SendNotificationToToken(orgID string, appID string, token string, title string, body string, data map[string]string) error {
+// SendNotificationToToken sends a notification to token. It gives the FCM message id on success.
+func (fa *Adapter) SendNotificationToToken(orgID string, appID string, token string, title string, body string, data map[string]string) (string, error) {
 	ctx := context.Background()
-	firebase := fa.getFirebaseClient(orgID, appID)
+	firebase, err := fa.getFirebaseClient(orgID, appID)
+	if err != nil {
+		return "", err
+	}
 	client, err := firebase.Messaging(ctx)
-	if err == nil {
-		message := &messaging.Message{
-			Token: token,
-			Data:  data,
-			Notification: &messaging.Notification{
-				Title: title,
-				Body:  body,
-			},
+	if err != nil {
+		return "", err
+	}
+
+	message := &messaging.Message{
+		Token: token,
+		Data:  data,
+		Notification: &messaging.Notification{
+			Title: title,
+			Body:  body,
+		},
+	}
+	messageID, err := client.Send(ctx, message)
+	if err != nil {
+		log.Printf("error while sending notification to token (%s): %s", token, err)
+		return "", &model.FirebaseSendError{Code: fa.getErrorCode(err), Token: token, Err: err}
+	}
+	return messageID, nil
+}
+
+// SendNotifications sends up to model.FirebaseMaxBatchSize notifications in one batch. It gives the result for every message in the same order.
+func (fa *Adapter) SendNotifications(orgID string, appID string, messages []model.FirebaseMessage) ([]model.FirebaseSendResult, error) {
+	if len(messages) == 0 {
+		return []model.FirebaseSendResult{}, nil
+	}
+	if len(messages) > model.FirebaseMaxBatchSize {
+		return nil, fmt.Errorf("too many messages in a batch: %d, max %d", len(messages), model.FirebaseMaxBatchSize)
+	}
+
+	ctx := context.Background()
+	firebase, err := fa.getFirebaseClient(orgID, appID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := firebase.Messaging(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fcmMessages := make([]*messaging.Message, len(messages))
+	for i, current := range messages {
+		fcmMessages[i] = fa.buildMessage(current)
+	}
+	//one batch call, the SDK bounds the number of the concurrent requests
+	response, err := client.SendEach(ctx, fcmMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]model.FirebaseSendResult, len(messages))
+	for i, current := range response.Responses {
+		token := messages[i].Token
+		results[i] = model.FirebaseSendResult{Token: token}
+		if current.Success {
+			results[i].MessageID = current.MessageID
+		} else {
+			results[i].Err = &model.FirebaseSendError{Code: fa.getErrorCode(current.Error), Token: token, Err: current.Error}
 		}
-		_, err = client.Send(ctx, message)
-		if err != nil {
-			log.Printf("error while sending notification to token (%s): %s", token, err)
-			err = fmt.Errorf("error while sending notification to token (%s): %s", token, err)
+	}
+	return results, nil
+}
+
+func (fa *Adapter) buildMessage(message model.FirebaseMessage) *messaging.Message {
+	result := &messaging.Message{
+		Token: message.Token,
+		Data:  message.Data,
+		Notification: &messaging.Notification{
+			Title: message.Title,
+			Body:  message.Body,
+		},
+	}
+
+	android := &messaging.AndroidConfig{}
+	apnsHeaders := map[string]string{}
+
+	//priority - the default one keeps the FCM defaults
+	switch {
+	case message.Priority > model.MessagePriorityDefault:
+		android.Priority = "high"
+		apnsHeaders["apns-priority"] = "10"
+	case message.Priority < model.MessagePriorityDefault:
+		android.Priority = "normal"
+		apnsHeaders["apns-priority"] = "5"
+	}
+
+	//expiration - FCM does not try to deliver the message after it
+	if message.ExpiresAt != nil {
+		ttl := max(time.Until(*message.ExpiresAt).Truncate(time.Second), 0)
+		android.TTL = &ttl
+		apnsHeaders["apns-expiration"] = strconv.FormatInt(message.ExpiresAt.Unix(), 10)
+	}
+
+	//collapse key - the device replaces the earlier notification with the same key
+	if message.CollapseKey != nil {
+		android.CollapseKey = *message.CollapseKey
+		apnsHeaders["apns-collapse-id"] = *message.CollapseKey
+	}
+
+	badge := fa.getBadge(message)
+	if message.Silent {
+		fa.applySilent(result, android, apnsHeaders, badge)
+		return result
+	}
+
+	if len(android.Priority) > 0 || android.TTL != nil || len(android.CollapseKey) > 0 {
+		result.Android = android
+	}
+	if len(apnsHeaders) > 0 {
+		result.APNS = &messaging.APNSConfig{Headers: apnsHeaders}
+	}
+
+	if message.PushOptions != nil {
+		fa.applyPushOptions(result, *message.PushOptions)
+	}
+
+	//badge
+	if badge != nil {
+		if result.APNS == nil {
+			result.APNS = &messaging.APNSConfig{}
+		}
+		if result.APNS.Payload == nil {
+			result.APNS.Payload = &messaging.APNSPayload{Aps: &messaging.Aps{}}
 		}
+		result.APNS.Payload.Aps.Badge = badge
+	}
+	return result
+}
+
+// getBadge gives the badge from the push options if set, otherwise the user unread messages count
+func (fa *Adapter) getBadge(message model.FirebaseMessage) *int {
+	var badge int
+	switch {
+	case message.PushOptions != nil && message.PushOptions.Badge != nil:
+		badge = *message.PushOptions.Badge
+	case message.Badge != nil:
+		badge = *message.Badge
+	default:
+		return nil
+	}
+	return &badge
+}
+
+// applySilent makes the message data-only so that it wakes the app without alerting the user.
+// Android needs high priority for waking the app, APNs needs a background push with normal priority.
+func (fa *Adapter) applySilent(message *messaging.Message, android *messaging.AndroidConfig, apnsHeaders map[string]string, badge *int) {
+	message.Notification = nil
+
+	android.Priority = "high"
+	message.Android = android
+
+	apnsHeaders["apns-push-type"] = "background"
+	apnsHeaders["apns-priority"] = "5"
+	aps := &messaging.Aps{ContentAvailable: true, Badge: badge} //the badge can be updated silently
+	message.APNS = &messaging.APNSConfig{Headers: apnsHeaders, Payload: &messaging.APNSPayload{Aps: aps}}
+}
+
+func (fa *Adapter) applyPushOptions(message *messaging.Message, options model.PushOptions) {
+	if message.Android == nil {
+		message.Android = &messaging.AndroidConfig{}
+	}
+	if message.APNS == nil {
+		message.APNS = &messaging.APNSConfig{}
+	}
+	androidNotification := &messaging.AndroidNotification{}
+	aps := &messaging.Aps{}
+	webpushNotification := &messaging.WebpushNotification{}
+
+	//sound
+	if options.Sound != nil {
+		androidNotification.Sound = *options.Sound
+		aps.Sound = *options.Sound
+	}
+
+	//image - iOS needs a notification service extension to show it, so the mutable content flag is set
+	if options.ImageURL != nil {
+		message.Notification.ImageURL = *options.ImageURL
+		androidNotification.ImageURL = *options.ImageURL
+		aps.MutableContent = true
+		message.APNS.FCMOptions = &messaging.APNSFCMOptions{ImageURL: *options.ImageURL}
+		webpushNotification.Image = *options.ImageURL
+	}
+
+	//android channel
+	if options.AndroidChannelID != nil {
+		androidNotification.ChannelID = *options.AndroidChannelID
+	}
+
+	//apns category
+	if options.APNSCategory != nil {
+		aps.Category = *options.APNSCategory
+	}
+
+	message.Android.Notification = androidNotification
+	message.APNS.Payload = &messaging.APNSPayload{Aps: aps}
+
+	//webpush link
+	var webpushOptions *messaging.WebpushFCMOptions
+	if options.WebpushLink != nil {
+		webpushOptions = &messaging.WebpushFCMOptions{Link: *options.WebpushLink}
+	}
+	if len(webpushNotification.Image) > 0 || webpushOptions != nil {
+		message.Webpush = &messaging.WebpushConfig{Notification: webpushNotification, FCMOptions: webpushOptions}
+	}
+}
+
+func (fa *Adapter) getErrorCode(err error) string {
+	switch {
+	case messaging.IsUnregistered(err):
+		return model.FirebaseErrorTokenNotRegistered
+	case messaging.IsInvalidArgument(err):
+		return model.FirebaseErrorInvalidArgument
+	case messaging.IsQuotaExceeded(err):
+		return model.FirebaseErrorMessageRateExceeded
+	case messaging.IsUnavailable(err):
+		return model.FirebaseErrorServerUnavailable
+	case messaging.IsInternal(err):
+		return model.FirebaseErrorInternal
+	case messaging.IsSenderIDMismatch(err):
+		return model.FirebaseErrorMismatchedCredential
+	case messaging.IsThirdPartyAuthError(err):
+		return model.FirebaseErrorInvalidAPNSCredentials
+	case errorutils.IsUnknown(err):
+		return model.FirebaseErrorUnknown
+	default:
+		return ""
 	}
-	return err
 }
 
 // SendNotificationToTopic sends a notification to a topic
 func (fa *Adapter) SendNotificationToTopic(orgID string, appID string, topic string, title string, body string, data map[string]string) error {
 	ctx := context.Background()
-	firebase := fa.getFirebaseClient(orgID, appID)
+	firebase, err := fa.getFirebaseClient(orgID, appID)
+	if err != nil {
+		return err
+	}
 	client, err := firebase.Messaging(ctx)
 	if err == nil {
 		message := &messaging.Message{
@@ -139,7 +349,10 @@ func (fa *Adapter) SendNotificationToTopic(orgID string, appID string, topic str
 // SubscribeToTopic subscribes to a topic
 func (fa *Adapter) SubscribeToTopic(orgID string, appID string, token string, topic string) error {
 	ctx := context.Background()
-	firebase := fa.getFirebaseClient(orgID, appID)
+	firebase, err := fa.getFirebaseClient(orgID, appID)
+	if err != nil {
+		return err
+	}
 	client, err := firebase.Messaging(ctx)
 	if err == nil {
 		_, err = client.SubscribeToTopic(ctx, []string{token}, topic)
@@ -153,7 +366,10 @@ func (fa *Adapter) SubscribeToTopic(orgID string, appID string, token string, to
 // UnsubscribeToTopic unsubscribes from a topic
 func (fa *Adapter) UnsubscribeToTopic(orgID string, appID string, token string, topic string) error {
 	ctx := context.Background()
-	firebase := fa.getFirebaseClient(orgID, appID)
+	firebase, err := fa.getFirebaseClient(orgID, appID)
+	if err != nil {
+		return err
+	}
 	client, err := firebase.Messaging(ctx)
 	if err == nil {
 		_, err = client.UnsubscribeFromTopic(ctx, []string{token}, topic)
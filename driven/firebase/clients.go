@@ -0,0 +1,116 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firebase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"notifications/core/model"
+	"sync"
+
+	firebase "firebase.google.com/go/v4"
+)
+
+// clientsRegistry keeps the firebase clients for the org/app pairs. It is safe for concurrent use.
+type clientsRegistry struct {
+	createClient func(conf model.FirebaseConf) (*firebase.App, error)
+
+	reconcileLock sync.Mutex //one reconciliation at a time
+
+	lock sync.RWMutex
+	//key is org-id_app-id construction, the map is replaced on every reconciliation and it is never modified after that
+	clients map[string]firebaseClient
+}
+
+type firebaseClient struct {
+	projectID string
+	auth      string
+	app       *firebase.App
+}
+
+func newClientsRegistry(createClient func(conf model.FirebaseConf) (*firebase.App, error)) *clientsRegistry {
+	return &clientsRegistry{createClient: createClient, clients: map[string]firebaseClient{}}
+}
+
+// get gives the client for the org/app pair, *model.FirebaseClientNotFoundError if there is no such client
+func (r *clientsRegistry) get(orgID string, appID string) (*firebase.App, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	client, ok := r.clients[r.getKey(orgID, appID)]
+	if !ok {
+		return nil, &model.FirebaseClientNotFoundError{OrgID: orgID, AppID: appID}
+	}
+	return client.app, nil
+}
+
+// count gives the number of clients
+func (r *clientsRegistry) count() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return len(r.clients)
+}
+
+// reconcile makes the clients match the configurations - it creates clients for the added and the changed configurations
+// and removes the clients of the removed configurations. A failed configuration does not affect the others and it keeps
+// its current client if there is one, the errors are given as *model.FirebaseConfError for every failed org/app pair.
+func (r *clientsRegistry) reconcile(confs []model.FirebaseConf) error {
+	r.reconcileLock.Lock()
+	defer r.reconcileLock.Unlock()
+
+	r.lock.RLock()
+	current := r.clients
+	r.lock.RUnlock()
+
+	//create the clients without holding the lock so that the senders are not blocked
+	clients := make(map[string]firebaseClient, len(confs))
+	var errs []error
+	for _, conf := range confs {
+		key := r.getKey(conf.OrgID, conf.AppID)
+		existing, exists := current[key]
+		if exists && existing.projectID == conf.ProjectID && existing.auth == conf.Auth {
+			clients[key] = existing //not changed
+			continue
+		}
+
+		app, err := r.createClient(conf)
+		if err != nil {
+			errs = append(errs, &model.FirebaseConfError{OrgID: conf.OrgID, AppID: conf.AppID, Err: err})
+			if exists {
+				clients[key] = existing //keep sending with the previous configuration until it gets fixed
+			}
+			continue
+		}
+		clients[key] = firebaseClient{projectID: conf.ProjectID, auth: conf.Auth, app: app}
+	}
+
+	for key := range current {
+		if _, ok := clients[key]; !ok {
+			log.Printf("removing firebase client %s", key)
+		}
+	}
+
+	r.lock.Lock()
+	r.clients = clients
+	r.lock.Unlock()
+
+	return errors.Join(errs...)
+}
+
+func (r *clientsRegistry) getKey(orgID string, appID string) string {
+	return fmt.Sprintf("%s_%s", orgID, appID)
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firebase
+
+import (
+	"errors"
+	"notifications/core/model"
+	"sync"
+	"testing"
+
+	firebase "firebase.google.com/go/v4"
+)
+
+// newTestRegistry gives a registry which fails to create the clients with "invalid" auth
+func newTestRegistry() *clientsRegistry {
+	return newClientsRegistry(func(conf model.FirebaseConf) (*firebase.App, error) {
+		if conf.Auth == "invalid" {
+			return nil, errors.New("invalid auth")
+		}
+		return &firebase.App{}, nil
+	})
+}
+
+func TestClientsReconcileAddsUpdatesAndRemoves(t *testing.T) {
+	registry := newTestRegistry()
+	err := registry.reconcile([]model.FirebaseConf{{OrgID: "org", AppID: "app1", Auth: "a"}, {OrgID: "org", AppID: "app2", Auth: "a"}})
+	if err != nil {
+		t.Fatalf("error on reconcile - %s", err)
+	}
+	app1, _ := registry.get("org", "app1")
+	app2, _ := registry.get("org", "app2")
+
+	err = registry.reconcile([]model.FirebaseConf{{OrgID: "org", AppID: "app1", Auth: "a"}, {OrgID: "org", AppID: "app2", Auth: "b"},
+		{OrgID: "org", AppID: "app3", Auth: "a"}})
+	if err != nil {
+		t.Fatalf("error on reconcile - %s", err)
+	}
+	if current, _ := registry.get("org", "app1"); current != app1 {
+		t.Errorf("the client of the not changed configuration has been recreated")
+	}
+	if current, _ := registry.get("org", "app2"); current == app2 {
+		t.Errorf("the client of the changed configuration has not been recreated")
+	}
+	if _, err := registry.get("org", "app3"); err != nil {
+		t.Errorf("the client of the added configuration is missing - %s", err)
+	}
+
+	err = registry.reconcile([]model.FirebaseConf{{OrgID: "org", AppID: "app3", Auth: "a"}})
+	if err != nil {
+		t.Fatalf("error on reconcile - %s", err)
+	}
+	if registry.count() != 1 {
+		t.Errorf("%d clients, expected only the one of app3", registry.count())
+	}
+}
+
+func TestClientsReconcileReportsFailedConfigurations(t *testing.T) {
+	registry := newTestRegistry()
+	registry.reconcile([]model.FirebaseConf{{OrgID: "org", AppID: "app1", Auth: "a"}})
+	app1, _ := registry.get("org", "app1")
+
+	err := registry.reconcile([]model.FirebaseConf{{OrgID: "org", AppID: "app1", Auth: "invalid"}, {OrgID: "org", AppID: "app2", Auth: "invalid"},
+		{OrgID: "org", AppID: "app3", Auth: "a"}})
+
+	var confErr *model.FirebaseConfError
+	if !errors.As(err, &confErr) {
+		t.Fatalf("error %v, expected firebase configuration errors", err)
+	}
+	if failed := len(err.(interface{ Unwrap() []error }).Unwrap()); failed != 2 {
+		t.Errorf("%d failed configurations, expected 2", failed)
+	}
+	if current, _ := registry.get("org", "app1"); current != app1 {
+		t.Errorf("the previous client has not been kept for the failed update")
+	}
+	if _, err := registry.get("org", "app3"); err != nil {
+		t.Errorf("the valid configuration has not been applied - %s", err)
+	}
+
+	_, err = registry.get("org", "app2")
+	var notFoundErr *model.FirebaseClientNotFoundError
+	if !errors.As(err, &notFoundErr) || notFoundErr.AppID != "app2" {
+		t.Errorf("error %v, expected missing client for app2", err)
+	}
+}
+
+func TestClientsAreSafeForConcurrentUse(t *testing.T) {
+	registry := newTestRegistry()
+	confs := [][]model.FirebaseConf{{{OrgID: "org", AppID: "app", Auth: "a"}}, {{OrgID: "org", AppID: "app", Auth: "b"}}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			registry.reconcile(confs[i%2])
+		}(i)
+		go func() {
+			defer wg.Done()
+			registry.get("org", "app")
+		}()
+	}
+	wg.Wait()
+
+	if registry.count() != 1 {
+		t.Errorf("%d clients, expected 1", registry.count())
+	}
+}
+
+func TestUpdateWithNoConfigurationsRemovesTheClients(t *testing.T) {
+	adapter := &Adapter{clients: newTestRegistry()}
+	err := adapter.Start([]model.FirebaseConf{{OrgID: "org", AppID: "app1", Auth: "a"}, {OrgID: "org", AppID: "app2", Auth: "a"}})
+	if err != nil {
+		t.Fatalf("error on start - %s", err)
+	}
+
+	err = adapter.UpdateFirebaseConfigurations([]model.FirebaseConf{})
+	if err != nil {
+		t.Fatalf("error on update - %s", err)
+	}
+	if count := adapter.clients.count(); count != 0 {
+		t.Errorf("%d clients, expected all clients to be removed", count)
+	}
+}
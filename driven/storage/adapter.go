@@ -196,9 +196,9 @@ func (sa Adapter) StoreFirebaseToken(orgID string, appID string, tokenInfo *mode
 		if userRecord == nil {
 			existingUser, _ := sa.findUserByIDWithContext(sessionContext, orgID, appID, userID)
 			if existingUser != nil {
-				err = sa.addTokenToUserWithContext(sessionContext, orgID, appID, userID, *tokenInfo.Token, tokenInfo.AppPlatform, tokenInfo.AppVersion)
+				err = sa.addTokenToUserWithContext(sessionContext, orgID, appID, userID, *tokenInfo.Token, tokenInfo.AppPlatform, tokenInfo.AppVersion, tokenInfo.Locale)
 			} else {
-				_, err = sa.createUserWithContext(sessionContext, orgID, appID, userID, *tokenInfo.Token, tokenInfo.AppPlatform, tokenInfo.AppVersion)
+				_, err = sa.createUserWithContext(sessionContext, orgID, appID, userID, *tokenInfo.Token, tokenInfo.AppPlatform, tokenInfo.AppVersion, tokenInfo.Locale)
 			}
 		} else if userRecord.UserID != userID {
 			err = sa.removeTokenFromUserWithContext(sessionContext, orgID, appID, *tokenInfo.Token, userRecord.UserID)
@@ -209,14 +209,17 @@ func (sa Adapter) StoreFirebaseToken(orgID string, appID string, tokenInfo *mode
 
 			existingUser, _ := sa.findUserByIDWithContext(sessionContext, orgID, appID, userID)
 			if existingUser != nil {
-				err = sa.addTokenToUserWithContext(sessionContext, orgID, appID, userID, *tokenInfo.Token, tokenInfo.AppPlatform, tokenInfo.AppVersion)
+				err = sa.addTokenToUserWithContext(sessionContext, orgID, appID, userID, *tokenInfo.Token, tokenInfo.AppPlatform, tokenInfo.AppVersion, tokenInfo.Locale)
 			} else {
-				_, err = sa.createUserWithContext(sessionContext, orgID, appID, userID, *tokenInfo.Token, tokenInfo.AppPlatform, tokenInfo.AppVersion)
+				_, err = sa.createUserWithContext(sessionContext, orgID, appID, userID, *tokenInfo.Token, tokenInfo.AppPlatform, tokenInfo.AppVersion, tokenInfo.Locale)
 			}
 			if err != nil {
 				fmt.Printf("error while linking token (%s) from user (%s)- %s\n", *tokenInfo.Token, userID, err)
 				return err
 			}
+		} else if tokenInfo.Locale != nil {
+			//the token is already linked to the user, keep the device locale up to date
+			err = sa.updateTokenLocaleWithContext(sessionContext, orgID, appID, userID, *tokenInfo.Token, *tokenInfo.Locale)
 		}
 
 		if err != nil {
@@ -238,7 +241,7 @@ func (sa Adapter) StoreFirebaseToken(orgID string, appID string, tokenInfo *mode
 	return err
 }
 
-func (sa Adapter) createUserWithContext(context context.Context, orgID string, appID string, userID string, token string, appPlatform *string, appVersion *string) (*model.User, error) {
+func (sa Adapter) createUserWithContext(context context.Context, orgID string, appID string, userID string, token string, appPlatform *string, appVersion *string, locale *string) (*model.User, error) {
 
 	now := time.Now().UTC()
 
@@ -248,6 +251,7 @@ func (sa Adapter) createUserWithContext(context context.Context, orgID string, a
 			Token:       token,
 			AppVersion:  appVersion,
 			AppPlatform: appPlatform,
+			Locale:      locale,
 			DateCreated: now,
 		})
 	}
@@ -270,7 +274,7 @@ func (sa Adapter) createUserWithContext(context context.Context, orgID string, a
 	return record, err
 }
 
-func (sa Adapter) addTokenToUserWithContext(ctx context.Context, orgID string, appID string, userID string, token string, appPlatform *string, appVersion *string) error {
+func (sa Adapter) addTokenToUserWithContext(ctx context.Context, orgID string, appID string, userID string, token string, appPlatform *string, appVersion *string, locale *string) error {
 	// transaction
 	filter := bson.D{
 		primitive.E{Key: "org_id", Value: orgID},
@@ -286,6 +290,7 @@ func (sa Adapter) addTokenToUserWithContext(ctx context.Context, orgID string, a
 			Token:       token,
 			AppVersion:  appVersion,
 			AppPlatform: appPlatform,
+			Locale:      locale,
 			DateCreated: time.Now().UTC(),
 		}}}},
 	}
@@ -310,6 +315,40 @@ func (sa Adapter) addTokenToUserWithContext(ctx context.Context, orgID string, a
 	return nil
 }
 
+func (sa Adapter) updateTokenLocaleWithContext(ctx context.Context, orgID string, appID string, userID string, token string, locale string) error {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "user_id", Value: userID},
+		primitive.E{Key: "firebase_tokens.token", Value: token},
+	}
+
+	now := time.Now().UTC()
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "firebase_tokens.$.locale", Value: locale},
+			primitive.E{Key: "firebase_tokens.$.date_updated", Value: now},
+			primitive.E{Key: "date_updated", Value: now},
+		}},
+	}
+
+	_, err := sa.db.users.UpdateOneWithContext(ctx, filter, &update, nil)
+	if err != nil {
+		fmt.Printf("warning: error while updating token (%s) locale for user (%s) %s\n", token, userID, err)
+		return err
+	}
+	return nil
+}
+
+// RemoveFirebaseTokenFromUser removes a firebase token from an user
+func (sa Adapter) RemoveFirebaseTokenFromUser(orgID string, appID string, userID string, token string) error {
+	err := sa.removeTokenFromUserWithContext(context.Background(), orgID, appID, token, userID)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionUpdate, "user", &logutils.FieldArgs{"user_id": userID}, err)
+	}
+	return nil
+}
+
 func (sa Adapter) removeTokenFromUserWithContext(ctx context.Context, orgID string, appID string, token string, userID string) error {
 	filter := bson.D{
 		primitive.E{Key: "org_id", Value: orgID},
@@ -444,8 +483,9 @@ func (sa Adapter) GetUsersByRecipientCriteriasWithContext(ctx context.Context, o
 	return nil, fmt.Errorf("no mapped recipients for the input criterias")
 }
 
-// UpdateUserByID Updates users notification enabled flag
-func (sa Adapter) UpdateUserByID(orgID string, appID string, userID string, notificationsDisabled bool) (*model.User, error) {
+// UpdateUserByID Updates users notification enabled flag. The quiet hours, the time zone and the digest are updated if given, the empty ones are removed.
+func (sa Adapter) UpdateUserByID(orgID string, appID string, userID string, notificationsDisabled bool, quietHours *model.QuietHours, timeZone *string,
+	digest *model.DigestSettings) (*model.User, error) {
 	if userID != "" {
 		filter := bson.D{
 			primitive.E{Key: "org_id", Value: orgID},
@@ -457,10 +497,35 @@ func (sa Adapter) UpdateUserByID(orgID string, appID string, userID string, noti
 			primitive.E{Key: "date_updated", Value: time.Now().UTC()},
 			primitive.E{Key: "notifications_disabled", Value: notificationsDisabled},
 		}
+		unset := bson.D{}
+		if quietHours != nil {
+			if quietHours.IsEmpty() {
+				unset = append(unset, primitive.E{Key: "quiet_hours", Value: ""})
+			} else {
+				innerUpdate = append(innerUpdate, primitive.E{Key: "quiet_hours", Value: quietHours})
+			}
+		}
+		if timeZone != nil {
+			if len(*timeZone) == 0 {
+				unset = append(unset, primitive.E{Key: "time_zone", Value: ""})
+			} else {
+				innerUpdate = append(innerUpdate, primitive.E{Key: "time_zone", Value: *timeZone})
+			}
+		}
+		if digest != nil {
+			if digest.IsEmpty() {
+				unset = append(unset, primitive.E{Key: "digest", Value: ""})
+			} else {
+				innerUpdate = append(innerUpdate, primitive.E{Key: "digest", Value: digest})
+			}
+		}
 
 		update := bson.D{
 			primitive.E{Key: "$set", Value: innerUpdate},
 		}
+		if len(unset) > 0 {
+			update = append(update, primitive.E{Key: "$unset", Value: unset})
+		}
 
 		_, err := sa.db.users.UpdateOneWithContext(context.Background(), filter, &update, nil)
 		if err != nil {
@@ -485,7 +550,7 @@ func (sa Adapter) DeleteUserWithID(orgID string, appID string, userID string) er
 				return err
 			}
 
-			messages, err := sa.FindMessagesRecipientsDeep(orgID, appID, &userID, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+			messages, err := sa.FindMessagesRecipientsDeep(orgID, appID, &userID, nil, nil, nil, nil, nil, nil, true, nil, nil, nil)
 			if err != nil {
 				fmt.Printf("warning: unable to retrieve messages for user (%s): %s\n", userID, err)
 				abortTransaction(sessionContext)
@@ -606,6 +671,38 @@ func (sa *Adapter) GetMessagesStats(orgID string, appID string, userID string) (
 	return &stats, nil
 }
 
+// FindUsersUnreadCounts counts the unread, not muted and not superseded messages of the users in one aggregation.
+// Only the messages shown in the inbox are counted - the sent and not expired ones.
+func (sa *Adapter) FindUsersUnreadCounts(usersIDs []string) ([]model.UserUnreadCount, error) {
+	if len(usersIDs) == 0 {
+		return []model.UserUnreadCount{}, nil
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"user_id": bson.M{"$in": usersIDs}, "read": bson.M{"$ne": true}, "mute": bson.M{"$ne": true}, "superseded": bson.M{"$ne": true}}},
+		{"$lookup": bson.M{
+			"from":         "messages",
+			"localField":   "message_id",
+			"foreignField": "_id",
+			"as":           "message",
+		}},
+		{"$unwind": "$message"},
+		{"$project": bson.M{"org_id": 1, "app_id": 1, "user_id": 1, "time": "$message.time", "expires_at": "$message.expires_at"}},
+	}
+	pipeline = append(pipeline, inboxMatchStages(time.Now(), false)...)
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": bson.M{"org_id": "$org_id", "app_id": "$app_id", "user_id": "$user_id"}, "count": bson.M{"$sum": 1}}},
+		bson.M{"$project": bson.M{"_id": 0, "org_id": "$_id.org_id", "app_id": "$_id.app_id", "user_id": "$_id.user_id", "count": 1}},
+	)
+
+	var result []model.UserUnreadCount
+	err := sa.db.messagesRecipients.Aggregate(pipeline, &result, nil)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "messages recipients", &logutils.FieldArgs{"users_ids": usersIDs}, err)
+	}
+	return result, nil
+}
+
 // SubscribeToTopic subscribes the token to a topic
 func (sa Adapter) SubscribeToTopic(orgID string, appID string, token string, userID string, topic string) error {
 	record, err := sa.FindUserByID(orgID, appID, userID)
@@ -792,9 +889,9 @@ func (sa Adapter) FindMessagesRecipientsByMessages(messagesIDs []string) ([]mode
 	return data, nil
 }
 
-// FindMessagesRecipientsDeep finds messages recipients join with messages
+// FindMessagesRecipientsDeep finds messages recipients join with messages. The expired messages are skipped unless includeExpired is set.
 func (sa Adapter) FindMessagesRecipientsDeep(orgID string, appID string, userID *string, read *bool, mute *bool,
-	messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string,
+	messageIDs []string, startDateEpoch *int64, endDateEpoch *int64, filterTopic *string, includeExpired bool,
 	offset *int64, limit *int64, order *string) ([]model.MessageRecipient, error) {
 
 	type recipientJoinMessage struct {
@@ -812,15 +909,22 @@ func (sa Adapter) FindMessagesRecipientsDeep(orgID string, appID string, userID
 		DateCreated               *time.Time                `bson:"date_created"`
 		DateUpdated               *time.Time                `bson:"date_updated"`
 		Time                      time.Time                 `bson:"time"`
+		ExpiresAt                 *time.Time                `bson:"expires_at"`
+		CollapseKey               *string                   `bson:"collapse_key"`
+		PushOptions               *model.PushOptions        `bson:"push_options"`
+		DeliveryMode              string                    `bson:"delivery_mode"`
+
+		Localizations map[string]model.MessageLocalization `bson:"localizations"`
 
 		//recipient
-		OrgID     string `bson:"org_id"`
-		AppID     string `bson:"app_id"`
-		ID        string `bson:"_id"`
-		UserID    string `bson:"user_id"`
-		MessageID string `bson:"message_id"`
-		Mute      bool   `bson:"mute"`
-		Read      bool   `bson:"read"`
+		OrgID      string `bson:"org_id"`
+		AppID      string `bson:"app_id"`
+		ID         string `bson:"_id"`
+		UserID     string `bson:"user_id"`
+		MessageID  string `bson:"message_id"`
+		Mute       bool   `bson:"mute"`
+		Read       bool   `bson:"read"`
+		Superseded bool   `bson:"superseded"`
 	}
 
 	pipeline := []bson.M{
@@ -832,8 +936,9 @@ func (sa Adapter) FindMessagesRecipientsDeep(orgID string, appID string, userID
 		}},
 		{"$unwind": "$message"},
 		{"$project": bson.M{"org_id": 1, "app_id": 1, "_id": 1,
-			"user_id": 1, "message_id": 1, "mute": 1, "read": 1, "time": "$message.time",
-			"priority": "$message.priority", "subject": "$message.subject", "sender": "$message.sender",
+			"user_id": 1, "message_id": 1, "mute": 1, "read": 1, "superseded": 1, "time": "$message.time", "expires_at": "$message.expires_at", "collapse_key": "$message.collapse_key",
+			"push_options": "$message.push_options", "delivery_mode": "$message.delivery_mode",
+			"localizations": "$message.localizations", "priority": "$message.priority", "subject": "$message.subject", "sender": "$message.sender",
 			"body": "$message.body", "data": "$message.data", "recipients": "$message.recipients",
 			"recipients_criteria_list": "$message.recipients_criteria_list", "recipient_account_criteria": "$message.recipient_account_criteria",
 			"topic": "$message.topic", "calculated_recipients_count": "$message.calculated_recipients_count",
@@ -862,7 +967,7 @@ func (sa Adapter) FindMessagesRecipientsDeep(orgID string, appID string, userID
 		pipeline = append(pipeline, bson.M{"$match": bson.M{"topic": *filterTopic}})
 	}
 
-	pipeline = append(pipeline, bson.M{"$match": bson.M{"time": bson.M{"$lte": time.Now()}}})
+	pipeline = append(pipeline, inboxMatchStages(time.Now(), includeExpired)...)
 
 	if startDateEpoch != nil {
 		seconds := *startDateEpoch / 1000
@@ -905,17 +1010,31 @@ func (sa Adapter) FindMessagesRecipientsDeep(orgID string, appID string, userID
 			Sender: item.Sender, Body: item.Body, Data: item.Data, Recipients: item.Recipients,
 			RecipientsCriteriaList: item.RecipientsCriteriaList, RecipientAccountCriteria: item.RecipientAccountCriteria,
 			Topic: item.Topic, CalculatedRecipientsCount: item.CalculatedRecipientsCount, DateCreated: item.DateCreated,
-			DateUpdated: item.DateUpdated, Time: item.Time}
+			DateUpdated: item.DateUpdated, Time: item.Time, ExpiresAt: item.ExpiresAt, CollapseKey: item.CollapseKey,
+			PushOptions: item.PushOptions, DeliveryMode: item.DeliveryMode, Localizations: item.Localizations}
 
 		recipient := model.MessageRecipient{OrgID: item.OrgID, AppID: item.AppID,
 			ID: item.ID, UserID: item.UserID, MessageID: item.MessageID, Mute: item.Mute,
-			Read: item.Read, Message: message}
+			Read: item.Read, Superseded: item.Superseded, Message: message}
 		result[i] = recipient
 	}
 
 	return result, nil
 }
 
+// inboxMatchStages gives the stages which keep the messages shown in the inbox at the time - the sent ones and, unless includeExpired is set, the not expired ones.
+// They expect the message time and expires_at to be projected on the recipient.
+func inboxMatchStages(now time.Time, includeExpired bool) []bson.M {
+	stages := []bson.M{{"$match": bson.M{"time": bson.M{"$lte": now}}}}
+	if !includeExpired {
+		stages = append(stages, bson.M{"$match": bson.M{"$or": bson.A{
+			bson.M{"expires_at": nil},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		}}})
+	}
+	return stages
+}
+
 // InsertMessagesRecipientsWithContext inserts messages recipients
 func (sa Adapter) InsertMessagesRecipientsWithContext(ctx context.Context, items []model.MessageRecipient) error {
 	if len(items) == 0 {
@@ -993,6 +1112,41 @@ func (sa Adapter) DeleteMessagesRecipientsForUsers(ctx context.Context, orgID st
 	return nil
 }
 
+// SupersedeMessagesRecipientsWithContext marks the users messages recipients of the messages with the collapse key as superseded
+func (sa Adapter) SupersedeMessagesRecipientsWithContext(ctx context.Context, orgID string, appID string, collapseKey string, usersIDs []string) error {
+	//find the messages with the collapse key
+	messagesFilter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "collapse_key", Value: collapseKey},
+	}
+	messagesIDs, err := sa.db.messages.DistinctWithContext(ctx, "_id", messagesFilter, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionFind, "message", &logutils.FieldArgs{"collapse_key": collapseKey}, err)
+	}
+	if len(messagesIDs) == 0 {
+		return nil
+	}
+
+	//mark their recipients
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "message_id", Value: bson.M{"$in": messagesIDs}},
+		primitive.E{Key: "user_id", Value: bson.M{"$in": usersIDs}},
+	}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "superseded", Value: true},
+		}},
+	}
+	_, err = sa.db.messagesRecipients.UpdateManyWithContext(ctx, filter, update, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionUpdate, "message recipient", &logutils.FieldArgs{"collapse_key": collapseKey}, err)
+	}
+	return nil
+}
+
 // FindMessagesWithContext finds messages by ids using context
 func (sa Adapter) FindMessagesWithContext(ctx context.Context, ids []string) ([]model.Message, error) {
 	filter := bson.D{primitive.E{Key: "_id", Value: bson.M{"$in": ids}}}
@@ -1176,6 +1330,64 @@ func (sa Adapter) DeleteMessagesWithContext(ctx context.Context, ids []string) e
 	return nil
 }
 
+// UpdateMessageTimeWithContext sets new time for a message, the expiration time is set too if given
+func (sa Adapter) UpdateMessageTimeWithContext(ctx context.Context, id string, messageTime time.Time, expiresAt *time.Time) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	fields := bson.D{
+		primitive.E{Key: "time", Value: messageTime},
+		primitive.E{Key: "date_updated", Value: time.Now().UTC()},
+	}
+	if expiresAt != nil {
+		fields = append(fields, primitive.E{Key: "expires_at", Value: *expiresAt})
+	}
+	update := bson.D{primitive.E{Key: "$set", Value: fields}}
+
+	res, err := sa.db.messages.UpdateOneWithContext(ctx, filter, update, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionUpdate, "message", &logutils.FieldArgs{"_id": id}, err)
+	}
+	if res.MatchedCount == 0 {
+		return errors.ErrorData(logutils.StatusMissing, "message", &logutils.FieldArgs{"_id": id})
+	}
+	return nil
+}
+
+// InsertMessageCancellationWithContext inserts a message cancellation record
+func (sa *Adapter) InsertMessageCancellationWithContext(ctx context.Context, item model.MessageCancellation) error {
+	_, err := sa.db.messageCancellations.InsertOneWithContext(ctx, item)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionInsert, "message cancellation", &logutils.FieldArgs{"message_id": item.MessageID}, err)
+	}
+	return nil
+}
+
+// FindMessageCancellations finds the message cancellation records for org/app, optionally only the ones for a message
+func (sa *Adapter) FindMessageCancellations(orgID string, appID string, messageID *string, offset *int64, limit *int64) ([]model.MessageCancellation, error) {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+	}
+	if messageID != nil {
+		filter = append(filter, primitive.E{Key: "message_id", Value: *messageID})
+	}
+
+	findOptions := options.Find()
+	if limit != nil {
+		findOptions.SetLimit(*limit)
+	}
+	if offset != nil {
+		findOptions.SetSkip(*offset)
+	}
+	findOptions.SetSort(bson.D{primitive.E{Key: "date_created", Value: -1}})
+
+	var result []model.MessageCancellation
+	err := sa.db.messageCancellations.Find(filter, &result, findOptions)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "message cancellations", nil, err)
+	}
+	return result, nil
+}
+
 // UpdateUnreadMessage updates a unread message in the recipients to read
 func (sa Adapter) UpdateUnreadMessage(ctx context.Context, orgID string, appID string, ID string, userID string) (*model.Message, error) {
 	read := true
@@ -1255,6 +1467,7 @@ func (sa Adapter) InsertQueueDataItemsWithContext(ctx context.Context, items []m
 
 	data := make([]interface{}, len(items))
 	for i, p := range items {
+		p.Slot = model.GetQueueSlot(p.UserID)
 		data[i] = p
 	}
 
@@ -1270,9 +1483,9 @@ func (sa Adapter) InsertQueueDataItemsWithContext(ctx context.Context, items []m
 	return nil
 }
 
-// LoadQueueWithContext loads the queue object
-func (sa Adapter) LoadQueueWithContext(ctx context.Context) (*model.Queue, error) {
-	filter := bson.D{}
+// LoadQueueWithContext loads a queue partition record
+func (sa Adapter) LoadQueueWithContext(ctx context.Context, id string) (*model.Queue, error) {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
 
 	var queue []model.Queue
 	err := sa.db.queue.FindWithContext(ctx, filter, &queue, nil)
@@ -1284,10 +1497,41 @@ func (sa Adapter) LoadQueueWithContext(ctx context.Context) (*model.Queue, error
 		return nil, nil
 	}
 
-	res := queue[0] //we support only one record
+	res := queue[0]
 	return &res, nil
 }
 
+// CreateQueueIfMissing creates a queue partition record if it does not exist
+func (sa *Adapter) CreateQueueIfMissing(queue model.Queue) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: queue.ID}}
+	update := bson.D{
+		primitive.E{Key: "$setOnInsert", Value: queue},
+	}
+	_, err := sa.db.queue.UpdateOne(filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionInsert, "queue", &logutils.FieldArgs{"_id": queue.ID}, err)
+	}
+	return nil
+}
+
+// UpdateQueueProcessItemsCount sets how many items are loaded at once for a queue partition
+func (sa *Adapter) UpdateQueueProcessItemsCount(id string, processItemsCount int) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "process_items_count", Value: processItemsCount},
+		}},
+	}
+	res, err := sa.db.queue.UpdateOne(filter, update, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionUpdate, "queue", &logutils.FieldArgs{"_id": id}, err)
+	}
+	if res.MatchedCount == 0 {
+		return errors.ErrorData(logutils.StatusMissing, "queue", &logutils.FieldArgs{"_id": id})
+	}
+	return nil
+}
+
 // SaveQueueWithContext saves queue with context
 func (sa *Adapter) SaveQueueWithContext(ctx context.Context, queue model.Queue) error {
 	filter := bson.D{primitive.E{Key: "_id", Value: queue.ID}}
@@ -1298,18 +1542,62 @@ func (sa *Adapter) SaveQueueWithContext(ctx context.Context, queue model.Queue)
 	return nil
 }
 
-// SaveQueue saves queue
-func (sa *Adapter) SaveQueue(queue model.Queue) error {
-	filter := bson.D{primitive.E{Key: "_id", Value: queue.ID}}
-	err := sa.db.queue.ReplaceOne(filter, queue, nil)
+// FindQueues finds all queue partition records
+func (sa *Adapter) FindQueues() ([]model.Queue, error) {
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{primitive.E{Key: "partition", Value: 1}})
+
+	var result []model.Queue
+	err := sa.db.queue.Find(bson.D{}, &result, findOptions)
 	if err != nil {
-		return errors.WrapErrorAction(logutils.ActionUpdate, "queue", &logutils.FieldArgs{"_id": queue.ID}, err)
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "queue", nil, err)
+	}
+	return result, nil
+}
+
+// RenewQueueLease extends the queue lease if it is still owned by the owner. It gives false if the lease has been lost.
+func (sa *Adapter) RenewQueueLease(queueID string, owner string, expiresAt time.Time) (bool, error) {
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: queueID},
+		primitive.E{Key: "status", Value: model.QueueStatusProcessing},
+		primitive.E{Key: "lease_owner", Value: owner},
+	}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "lease_expires_at", Value: expiresAt},
+			primitive.E{Key: "lease_renewed_at", Value: time.Now().UTC()},
+		}},
+	}
+	res, err := sa.db.queue.UpdateOne(filter, update, nil)
+	if err != nil {
+		return false, errors.WrapErrorAction(logutils.ActionUpdate, "queue lease", &logutils.FieldArgs{"_id": queueID}, err)
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// ReleaseQueueLease marks the queue ready if its lease is still owned by the owner
+func (sa *Adapter) ReleaseQueueLease(queueID string, owner string) error {
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: queueID},
+		primitive.E{Key: "lease_owner", Value: owner},
+	}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "status", Value: model.QueueStatusReady},
+			primitive.E{Key: "lease_owner", Value: ""},
+			primitive.E{Key: "lease_expires_at", Value: nil},
+		}},
+	}
+	_, err := sa.db.queue.UpdateOne(filter, update, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionUpdate, "queue lease", &logutils.FieldArgs{"_id": queueID}, err)
 	}
 	return nil
 }
 
-// FindQueueData finds queue data
-func (sa *Adapter) FindQueueData(time *time.Time, limit int) ([]model.QueueItem, error) {
+// FindQueueData finds queue data. When partitionsCount is greater than one it gives only the items of the partition.
+// The items are ordered by time unless byPriority is set - then the most urgent(highest priority) items come first.
+func (sa *Adapter) FindQueueData(time *time.Time, partition int, partitionsCount int, byPriority bool, excludeIDs []string, pauses []model.QueuePause, limit int) ([]model.QueueItem, error) {
 	filter := bson.D{}
 
 	//time
@@ -1317,12 +1605,35 @@ func (sa *Adapter) FindQueueData(time *time.Time, limit int) ([]model.QueueItem,
 		filter = append(filter, primitive.E{Key: "time", Value: bson.M{"$lte": time}})
 	}
 
+	//exclude
+	if len(excludeIDs) > 0 {
+		filter = append(filter, primitive.E{Key: "_id", Value: bson.M{"$nin": excludeIDs}})
+	}
+
+	//paused org/apps
+	if len(pauses) > 0 {
+		paused := make(bson.A, len(pauses))
+		for i, pause := range pauses {
+			paused[i] = bson.D{primitive.E{Key: "org_id", Value: pause.OrgID}, primitive.E{Key: "app_id", Value: pause.AppID}}
+		}
+		filter = append(filter, primitive.E{Key: "$nor", Value: paused})
+	}
+
+	//partition
+	if partitionsCount > 1 {
+		filter = append(filter, primitive.E{Key: "slot", Value: bson.M{"$mod": bson.A{partitionsCount, partition}}})
+	}
+
 	//set limit
 	findOptions := options.Find()
 	findOptions.SetLimit(int64(limit))
 
 	//set sort
-	findOptions.SetSort(bson.D{primitive.E{Key: "time", Value: 1}, primitive.E{Key: "priority", Value: 1}})
+	if byPriority {
+		findOptions.SetSort(bson.D{primitive.E{Key: "priority", Value: -1}, primitive.E{Key: "time", Value: 1}})
+	} else {
+		findOptions.SetSort(bson.D{primitive.E{Key: "time", Value: 1}, primitive.E{Key: "priority", Value: -1}})
+	}
 
 	var result []model.QueueItem
 	err := sa.db.queueData.Find(filter, &result, findOptions)
@@ -1332,6 +1643,73 @@ func (sa *Adapter) FindQueueData(time *time.Time, limit int) ([]model.QueueItem,
 	return result, nil
 }
 
+// UpdateQueueDataTimes sets new time for the queue data items - queue item id -> time
+func (sa *Adapter) UpdateQueueDataTimes(times map[string]time.Time) error {
+	if len(times) == 0 {
+		return nil
+	}
+
+	//one round trip for all items
+	models := make([]mongo.WriteModel, 0, len(times))
+	for id, time := range times {
+		filter := bson.D{primitive.E{Key: "_id", Value: id}}
+		update := bson.D{
+			primitive.E{Key: "$set", Value: bson.D{
+				primitive.E{Key: "time", Value: time},
+			}},
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+	}
+	_, err := sa.db.queueData.BulkWrite(models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionUpdate, "queue data", &logutils.FieldArgs{"count": len(times)}, err)
+	}
+	return nil
+}
+
+// UpdateQueueDataForDigest marks the queue data items as collected for the users digests and sets the time when the digest is sent - queue item id -> time
+func (sa *Adapter) UpdateQueueDataForDigest(times map[string]time.Time) error {
+	if len(times) == 0 {
+		return nil
+	}
+
+	//one round trip for all items
+	models := make([]mongo.WriteModel, 0, len(times))
+	for id, time := range times {
+		filter := bson.D{primitive.E{Key: "_id", Value: id}}
+		update := bson.D{
+			primitive.E{Key: "$set", Value: bson.D{
+				primitive.E{Key: "time", Value: time},
+				primitive.E{Key: "digest", Value: true},
+			}},
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+	}
+	_, err := sa.db.queueData.BulkWrite(models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionUpdate, "queue data", &logutils.FieldArgs{"count": len(times)}, err)
+	}
+	return nil
+}
+
+// FindQueueDataForDigest finds the org/app users queue data items collected for the digests which are due
+func (sa *Adapter) FindQueueDataForDigest(orgID string, appID string, usersIDs []string, time time.Time) ([]model.QueueItem, error) {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "user_id", Value: bson.M{"$in": usersIDs}},
+		primitive.E{Key: "digest", Value: true},
+		primitive.E{Key: "time", Value: bson.M{"$lte": time}},
+	}
+
+	var result []model.QueueItem
+	err := sa.db.queueData.Find(filter, &result, nil)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "queue data", nil, err)
+	}
+	return result, nil
+}
+
 // DeleteQueueData removes queue data
 func (sa *Adapter) DeleteQueueData(ids []string) error {
 	filter := bson.D{primitive.E{Key: "_id", Value: bson.M{"$in": ids}}}
@@ -1342,6 +1720,57 @@ func (sa *Adapter) DeleteQueueData(ids []string) error {
 	return nil
 }
 
+// UpdateQueueDataTimeForMessageWithContext sets new time for the message queue data items, the expiration time is set too if given
+func (sa *Adapter) UpdateQueueDataTimeForMessageWithContext(ctx context.Context, messageID string, messageTime time.Time, expiresAt *time.Time) error {
+	filter := bson.D{primitive.E{Key: "message_id", Value: messageID}}
+	fields := bson.D{primitive.E{Key: "time", Value: messageTime}}
+	if expiresAt != nil {
+		fields = append(fields, primitive.E{Key: "expires_at", Value: *expiresAt})
+	}
+	update := bson.D{primitive.E{Key: "$set", Value: fields}}
+
+	_, err := sa.db.queueData.UpdateManyWithContext(ctx, filter, update, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionUpdate, "queue data", &logutils.FieldArgs{"message_id": messageID}, err)
+	}
+	return nil
+}
+
+// CountQueueDataForMessageWithContext counts the message queue data items
+func (sa *Adapter) CountQueueDataForMessageWithContext(ctx context.Context, messageID string) (int64, error) {
+	filter := bson.D{primitive.E{Key: "message_id", Value: messageID}}
+
+	count, err := sa.db.queueData.CountDocumentsWithContext(ctx, filter)
+	if err != nil {
+		return 0, errors.WrapErrorAction(logutils.ActionCount, "queue data", &logutils.FieldArgs{"message_id": messageID}, err)
+	}
+	return count, nil
+}
+
+// FindQueueDataPending gives the pending queue data items count for the org/app messages, optionally filtered by message
+func (sa *Adapter) FindQueueDataPending(orgID string, appID string, messageID *string) ([]model.QueueMessagePending, error) {
+	match := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+	}
+	if messageID != nil {
+		match = append(match, primitive.E{Key: "message_id", Value: *messageID})
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{"_id": "$message_id", "count": bson.M{"$sum": 1}, "next_time": bson.M{"$min": "$time"}}},
+		{"$sort": bson.M{"next_time": 1}},
+	}
+
+	var result []model.QueueMessagePending
+	err := sa.db.queueData.Aggregate(pipeline, &result, nil)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "queue data", &logutils.FieldArgs{"org_id": orgID, "app_id": appID}, err)
+	}
+	return result, nil
+}
+
 // DeleteQueueDataForMessagesWithContext removes queue data items for messages
 func (sa *Adapter) DeleteQueueDataForMessagesWithContext(ctx context.Context, messagesIDs []string) error {
 	filter := bson.D{primitive.E{Key: "message_id", Value: bson.M{"$in": messagesIDs}}}
@@ -1379,6 +1808,22 @@ func (sa *Adapter) DeleteQueueDataForUsers(ctx context.Context, orgID string, ap
 	return nil
 }
 
+// DeleteQueueDataForCollapseKeyWithContext removes the users queue data items with the collapse key
+func (sa *Adapter) DeleteQueueDataForCollapseKeyWithContext(ctx context.Context, orgID string, appID string, collapseKey string, usersIDs []string) error {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "collapse_key", Value: collapseKey},
+		primitive.E{Key: "user_id", Value: bson.M{"$in": usersIDs}},
+	}
+
+	_, err := sa.db.queueData.DeleteManyWithContext(ctx, filter, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionDelete, "queue data", &logutils.FieldArgs{"collapse_key": collapseKey}, err)
+	}
+	return nil
+}
+
 // FindQueueDataByUserID gets all queue data by userID
 func (sa Adapter) FindQueueDataByUserID(userID string) ([]model.QueueItem, error) {
 	filter := bson.D{
@@ -1394,6 +1839,334 @@ func (sa Adapter) FindQueueDataByUserID(userID string) ([]model.QueueItem, error
 	return queue, nil
 }
 
+// InsertDeliveryAttempts inserts delivery attempts
+func (sa *Adapter) InsertDeliveryAttempts(items []model.DeliveryAttempt) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	data := make([]interface{}, len(items))
+	for i, p := range items {
+		data[i] = p
+	}
+
+	res, err := sa.db.deliveryAttempts.InsertMany(data, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionInsert, "delivery attempts", nil, err)
+	}
+
+	if len(res.InsertedIDs) != len(items) {
+		return errors.ErrorAction(logutils.ActionInsert, "delivery attempts", &logutils.FieldArgs{"inserted": len(res.InsertedIDs), "expected": len(items)})
+	}
+
+	return nil
+}
+
+// FindDeliveryAttempts finds delivery attempts for a message and/or user
+func (sa *Adapter) FindDeliveryAttempts(orgID string, appID string, messageID *string, userID *string, offset *int64, limit *int64) ([]model.DeliveryAttempt, error) {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+	}
+	if messageID != nil {
+		filter = append(filter, primitive.E{Key: "message_id", Value: *messageID})
+	}
+	if userID != nil {
+		filter = append(filter, primitive.E{Key: "user_id", Value: *userID})
+	}
+
+	findOptions := options.Find()
+	//limit
+	limitValue := int64(100) //by default - 100
+	if limit != nil {
+		limitValue = *limit
+	}
+	findOptions.SetLimit(limitValue)
+	//offset
+	if offset != nil {
+		findOptions.SetSkip(*offset)
+	}
+	//sort
+	findOptions.SetSort(bson.D{primitive.E{Key: "date_created", Value: -1}})
+
+	var result []model.DeliveryAttempt
+	err := sa.db.deliveryAttempts.Find(filter, &result, findOptions)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "delivery attempts", nil, err)
+	}
+	return result, nil
+}
+
+// InsertTokenPrune inserts a token prune record
+func (sa *Adapter) InsertTokenPrune(item model.TokenPrune) error {
+	_, err := sa.db.tokenPrunes.InsertOne(item)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionInsert, "token prune", nil, err)
+	}
+	return nil
+}
+
+// FindQueuePauses finds all paused org/apps
+func (sa *Adapter) FindQueuePauses() ([]model.QueuePause, error) {
+	var result []model.QueuePause
+	err := sa.db.queuePauses.Find(bson.D{}, &result, nil)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "queue pause", nil, err)
+	}
+	return result, nil
+}
+
+// FindQueuePause finds the queue pause for org/app
+func (sa *Adapter) FindQueuePause(orgID string, appID string) (*model.QueuePause, error) {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+	}
+
+	var result []model.QueuePause
+	err := sa.db.queuePauses.Find(filter, &result, nil)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "queue pause", &logutils.FieldArgs{"org_id": orgID, "app_id": appID}, err)
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return &result[0], nil
+}
+
+// CreateQueuePauseIfMissing pauses the queue processing for org/app if it is not paused yet
+func (sa *Adapter) CreateQueuePauseIfMissing(pause model.QueuePause) error {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: pause.OrgID},
+		primitive.E{Key: "app_id", Value: pause.AppID},
+	}
+	update := bson.D{
+		primitive.E{Key: "$setOnInsert", Value: pause},
+	}
+	_, err := sa.db.queuePauses.UpdateOne(filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionInsert, "queue pause", &logutils.FieldArgs{"org_id": pause.OrgID, "app_id": pause.AppID}, err)
+	}
+	return nil
+}
+
+// DeleteQueuePause resumes the queue processing for org/app. It gives false if it has not been paused.
+func (sa *Adapter) DeleteQueuePause(orgID string, appID string) (bool, error) {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+	}
+	res, err := sa.db.queuePauses.DeleteOne(filter, nil)
+	if err != nil {
+		return false, errors.WrapErrorAction(logutils.ActionDelete, "queue pause", &logutils.FieldArgs{"org_id": orgID, "app_id": appID}, err)
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// InsertQueueDeadLetters inserts queue dead letters
+func (sa *Adapter) InsertQueueDeadLetters(items []model.QueueDeadLetter) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	data := make([]interface{}, len(items))
+	for i, p := range items {
+		data[i] = p
+	}
+
+	res, err := sa.db.queueDeadLetters.InsertMany(data, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionInsert, "queue dead letters", nil, err)
+	}
+
+	if len(res.InsertedIDs) != len(items) {
+		return errors.ErrorAction(logutils.ActionInsert, "queue dead letters", &logutils.FieldArgs{"inserted": len(res.InsertedIDs), "expected": len(items)})
+	}
+
+	return nil
+}
+
+// FindQueueDeadLettersWithContext finds queue dead letters for org/app, optionally filtered by ids and message
+func (sa *Adapter) FindQueueDeadLettersWithContext(ctx context.Context, orgID string, appID string, ids []string, messageID *string, offset *int64, limit *int64) ([]model.QueueDeadLetter, error) {
+	filter := sa.getQueueDeadLettersFilter(orgID, appID, ids, messageID)
+
+	findOptions := options.Find()
+	if limit != nil {
+		findOptions.SetLimit(*limit)
+	}
+	if offset != nil {
+		findOptions.SetSkip(*offset)
+	}
+	findOptions.SetSort(bson.D{primitive.E{Key: "date_created", Value: -1}})
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var result []model.QueueDeadLetter
+	err := sa.db.queueDeadLetters.FindWithContext(ctx, filter, &result, findOptions)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "queue dead letters", nil, err)
+	}
+	return result, nil
+}
+
+// DeleteQueueDeadLettersWithContext removes queue dead letters for org/app, optionally filtered by ids and message
+func (sa *Adapter) DeleteQueueDeadLettersWithContext(ctx context.Context, orgID string, appID string, ids []string, messageID *string) (int64, error) {
+	filter := sa.getQueueDeadLettersFilter(orgID, appID, ids, messageID)
+
+	res, err := sa.db.queueDeadLetters.DeleteManyWithContext(ctx, filter, nil)
+	if err != nil {
+		return 0, errors.WrapErrorAction(logutils.ActionDelete, "queue dead letters", nil, err)
+	}
+	return res.DeletedCount, nil
+}
+
+func (sa *Adapter) getQueueDeadLettersFilter(orgID string, appID string, ids []string, messageID *string) bson.D {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+	}
+	if ids != nil {
+		filter = append(filter, primitive.E{Key: "_id", Value: bson.M{"$in": ids}})
+	}
+	if messageID != nil {
+		filter = append(filter, primitive.E{Key: "message_id", Value: *messageID})
+	}
+	return filter
+}
+
+// InsertMessageSchedule inserts a message schedule
+func (sa *Adapter) InsertMessageSchedule(schedule model.MessageSchedule) error {
+	_, err := sa.db.messageSchedules.InsertOne(schedule)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionInsert, "message schedule", &logutils.FieldArgs{"_id": schedule.ID}, err)
+	}
+	return nil
+}
+
+// FindMessageSchedules finds the message schedules for org/app, optionally filtered by status
+func (sa *Adapter) FindMessageSchedules(orgID string, appID string, status *string, offset *int64, limit *int64) ([]model.MessageSchedule, error) {
+	filter := bson.D{
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "app_id", Value: appID},
+	}
+	if status != nil {
+		filter = append(filter, primitive.E{Key: "status", Value: *status})
+	}
+
+	findOptions := options.Find()
+	if limit != nil {
+		findOptions.SetLimit(*limit)
+	}
+	if offset != nil {
+		findOptions.SetSkip(*offset)
+	}
+	findOptions.SetSort(bson.D{primitive.E{Key: "date_created", Value: -1}})
+
+	var result []model.MessageSchedule
+	err := sa.db.messageSchedules.Find(filter, &result, findOptions)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "message schedules", nil, err)
+	}
+	return result, nil
+}
+
+// FindMessageSchedule finds a message schedule by id
+func (sa *Adapter) FindMessageSchedule(id string) (*model.MessageSchedule, error) {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+
+	var result []model.MessageSchedule
+	err := sa.db.messageSchedules.Find(filter, &result, nil)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "message schedule", &logutils.FieldArgs{"_id": id}, err)
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return &result[0], nil
+}
+
+// FindActiveMessageSchedules finds the active message schedules ordered by their next run, optionally only the ones due by the time
+func (sa *Adapter) FindActiveMessageSchedules(dueTime *time.Time, limit int64) ([]model.MessageSchedule, error) {
+	nextRunFilter := bson.D{primitive.E{Key: "$ne", Value: nil}}
+	if dueTime != nil {
+		nextRunFilter = append(nextRunFilter, primitive.E{Key: "$lte", Value: *dueTime})
+	}
+	filter := bson.D{
+		primitive.E{Key: "status", Value: model.MessageScheduleStatusActive},
+		primitive.E{Key: "next_run_at", Value: nextRunFilter},
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{primitive.E{Key: "next_run_at", Value: 1}})
+	findOptions.SetLimit(limit)
+
+	var result []model.MessageSchedule
+	err := sa.db.messageSchedules.Find(filter, &result, findOptions)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionFind, "message schedules", nil, err)
+	}
+	return result, nil
+}
+
+// UpdateMessageScheduleStatus sets the message schedule status and its next run
+func (sa *Adapter) UpdateMessageScheduleStatus(id string, status string, nextRunAt *time.Time) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "status", Value: status},
+			primitive.E{Key: "next_run_at", Value: nextRunAt},
+			primitive.E{Key: "date_updated", Value: time.Now().UTC()},
+		}},
+	}
+	res, err := sa.db.messageSchedules.UpdateOne(filter, update, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionUpdate, "message schedule", &logutils.FieldArgs{"_id": id}, err)
+	}
+	if res.MatchedCount == 0 {
+		return errors.ErrorData(logutils.StatusMissing, "message schedule", &logutils.FieldArgs{"_id": id})
+	}
+	return nil
+}
+
+// UpdateMessageScheduleRunWithContext records a message schedule run and moves it to the next one. The run is recorded only if the schedule
+// is still active and waits for the same run, so it gives false if the run has already been made by another instance or the schedule has been paused.
+func (sa *Adapter) UpdateMessageScheduleRunWithContext(ctx context.Context, id string, runAt time.Time, nextRunAt *time.Time, messageID string) (bool, error) {
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: id},
+		primitive.E{Key: "status", Value: model.MessageScheduleStatusActive},
+		primitive.E{Key: "next_run_at", Value: runAt},
+	}
+	now := time.Now().UTC()
+	update := bson.D{
+		primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "next_run_at", Value: nextRunAt},
+			primitive.E{Key: "last_run_at", Value: now},
+			primitive.E{Key: "last_message_id", Value: messageID},
+			primitive.E{Key: "date_updated", Value: now},
+		}},
+		primitive.E{Key: "$inc", Value: bson.D{
+			primitive.E{Key: "runs_count", Value: 1},
+		}},
+	}
+	res, err := sa.db.messageSchedules.UpdateOneWithContext(ctx, filter, update, nil)
+	if err != nil {
+		return false, errors.WrapErrorAction(logutils.ActionUpdate, "message schedule", &logutils.FieldArgs{"_id": id}, err)
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// DeleteMessageSchedule removes a message schedule
+func (sa *Adapter) DeleteMessageSchedule(id string) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	_, err := sa.db.messageSchedules.DeleteOne(filter, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionDelete, "message schedule", &logutils.FieldArgs{"_id": id}, err)
+	}
+	return nil
+}
+
 func abortTransaction(sessionContext mongo.SessionContext) {
 	err := sessionContext.AbortTransaction(sessionContext)
 	if err != nil {
@@ -1411,6 +2184,7 @@ func (sa *Adapter) abortTransaction(sessionContext mongo.SessionContext) {
 // Listener represents storage listener
 type Listener interface {
 	OnFirebaseConfigurationsUpdated()
+	OnQueueDataInserted()
 }
 
 // TransactionContext represents storage transaction interface
@@ -185,22 +185,6 @@ func (collWrapper *collectionWrapper) DeleteManyWithContext(ctx context.Context,
 	return result, nil
 }
 
-func (collWrapper *collectionWrapper) DeleteManyWithContextTMP(ctx context.Context, filter interface{}, opts *options.DeleteOptions) (*mongo.DeleteResult, error) {
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(5)*time.Minute) //for now...!
-	defer cancel()
-
-	result, err := collWrapper.coll.DeleteMany(ctx, filter, opts)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
-
 func (collWrapper *collectionWrapper) DeleteOne(filter interface{}, opts *options.DeleteOptions) (*mongo.DeleteResult, error) {
 	return collWrapper.DeleteOneWithContext(context.Background(), filter, opts)
 }
@@ -257,6 +241,25 @@ func (collWrapper *collectionWrapper) UpdateManyWithContextTimeout(ctx context.C
 	return updateResult, nil
 }
 
+func (collWrapper *collectionWrapper) BulkWrite(models []mongo.WriteModel, opts *options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	return collWrapper.BulkWriteWithContext(context.Background(), models, opts)
+}
+
+func (collWrapper *collectionWrapper) BulkWriteWithContext(ctx context.Context, models []mongo.WriteModel, opts *options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, collWrapper.database.mongoTimeout)
+	defer cancel()
+
+	bulkResult, err := collWrapper.coll.BulkWrite(ctx, models, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return bulkResult, nil
+}
+
 func (collWrapper *collectionWrapper) CountDocuments(filter interface{}) (int64, error) {
 	return collWrapper.CountDocumentsWithContext(context.Background(), filter)
 }
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"notifications/core/model"
 	"time"
 
 	"github.com/rokwire/rokwire-building-block-sdk-go/utils/errors"
@@ -46,6 +47,14 @@ type database struct {
 	messagesRecipients *collectionWrapper
 	queue              *collectionWrapper
 	queueData          *collectionWrapper
+	queuePauses        *collectionWrapper
+
+	deliveryAttempts *collectionWrapper
+	queueDeadLetters *collectionWrapper
+	tokenPrunes      *collectionWrapper
+	messageSchedules *collectionWrapper
+
+	messageCancellations *collectionWrapper
 
 	appVersions  *collectionWrapper
 	appPlatforms *collectionWrapper
@@ -118,6 +127,42 @@ func (m *database) start() error {
 		return err
 	}
 
+	deliveryAttempts := &collectionWrapper{database: m, coll: db.Collection("delivery_attempts")}
+	err = m.applyDeliveryAttemptsChecks(deliveryAttempts)
+	if err != nil {
+		return err
+	}
+
+	queuePauses := &collectionWrapper{database: m, coll: db.Collection("queue_pauses")}
+	err = m.applyQueuePausesChecks(queuePauses)
+	if err != nil {
+		return err
+	}
+
+	queueDeadLetters := &collectionWrapper{database: m, coll: db.Collection("queue_dead_letters")}
+	err = m.applyQueueDeadLettersChecks(queueDeadLetters)
+	if err != nil {
+		return err
+	}
+
+	tokenPrunes := &collectionWrapper{database: m, coll: db.Collection("token_prunes")}
+	err = m.applyTokenPrunesChecks(tokenPrunes)
+	if err != nil {
+		return err
+	}
+
+	messageSchedules := &collectionWrapper{database: m, coll: db.Collection("message_schedules")}
+	err = m.applyMessageSchedulesChecks(messageSchedules)
+	if err != nil {
+		return err
+	}
+
+	messageCancellations := &collectionWrapper{database: m, coll: db.Collection("message_cancellations")}
+	err = m.applyMessageCancellationsChecks(messageCancellations)
+	if err != nil {
+		return err
+	}
+
 	appPlatforms := &collectionWrapper{database: m, coll: db.Collection("app_platforms")}
 	err = m.applyPlatformsChecks(appPlatforms)
 	if err != nil {
@@ -146,15 +191,26 @@ func (m *database) start() error {
 	m.messagesRecipients = messagesRecipients
 	m.queue = queue
 	m.queueData = queueData
+	m.queuePauses = queuePauses
+	m.deliveryAttempts = deliveryAttempts
+	m.queueDeadLetters = queueDeadLetters
+	m.tokenPrunes = tokenPrunes
+	m.messageSchedules = messageSchedules
+	m.messageCancellations = messageCancellations
 	m.appPlatforms = appPlatforms
 	m.appVersions = appVersions
 	m.firebaseConfigurations = firebaseConfigurations
 
 	go m.firebaseConfigurations.Watch(nil)
-	go m.queueData.Watch(nil)
+	//only the inserts are of interest, the queue items themselves are not needed
+	queueDataPipeline := []bson.M{
+		{"$match": bson.M{"operationType": "insert"}},
+		{"$project": bson.M{"operationType": 1, "ns": 1}},
+	}
+	go m.queueData.Watch(queueDataPipeline)
 
 	//fix queue data - TMP
-	err = m.fixQueueData(queueData, queue)
+	err = m.fixQueueData(queueData)
 	if err != nil {
 		return err
 	}
@@ -163,15 +219,9 @@ func (m *database) start() error {
 	return nil
 }
 
-func (m *database) fixQueueData(queueData *collectionWrapper, queue *collectionWrapper) error {
-	//remove all items with time in the past
-	err := m.fixRemoveOldItems(queueData)
-	if err != nil {
-		return err
-	}
-
-	//set ready
-	err = m.fixSetReady(queue)
+func (m *database) fixQueueData(queueData *collectionWrapper) error {
+	//set the partition slot of the items created before the partitioning
+	err := m.fixSetMissingSlots(queueData)
 	if err != nil {
 		return err
 	}
@@ -181,37 +231,35 @@ func (m *database) fixQueueData(queueData *collectionWrapper, queue *collectionW
 	return nil
 }
 
-func (m *database) fixRemoveOldItems(queueData *collectionWrapper) error {
-	filter := bson.D{primitive.E{Key: "time", Value: bson.M{"$lt": time.Now()}}}
+func (m *database) fixSetMissingSlots(queueData *collectionWrapper) error {
+	filter := bson.D{primitive.E{Key: "slot", Value: bson.M{"$exists": false}}}
+	findOptions := options.Find().SetProjection(bson.D{primitive.E{Key: "user_id", Value: 1}}).SetLimit(1000)
 
-	deleteResult, err := queueData.DeleteManyWithContextTMP(context.Background(), filter, nil)
-	if err != nil {
-		return errors.WrapErrorAction(logutils.ActionDelete, "queue data", nil, err)
-	}
-	if deleteResult == nil {
-		return errors.New("deleteResult is nil")
-	}
-
-	fmt.Printf("old queue data items removed: %d\n", deleteResult.DeletedCount)
-	return nil
-}
-
-func (m *database) fixSetReady(queue *collectionWrapper) error {
+	updated := 0
+	for {
+		var items []model.QueueItem
+		err := queueData.Find(filter, &items, findOptions)
+		if err != nil {
+			return errors.WrapErrorAction(logutils.ActionFind, "queue data", nil, err)
+		}
+		if len(items) == 0 {
+			break
+		}
 
-	filter := bson.D{
-		primitive.E{Key: "_id", Value: "1"},
-	}
-	update := bson.D{
-		primitive.E{Key: "$set", Value: bson.D{
-			primitive.E{Key: "status", Value: "ready"},
-		}},
-	}
-	_, err := queue.UpdateOne(filter, update, nil)
-	if err != nil {
-		return err
+		//the slot is a hash of the user id, so it cannot be calculated by the database
+		models := make([]mongo.WriteModel, len(items))
+		for i, item := range items {
+			models[i] = mongo.NewUpdateOneModel().SetFilter(bson.D{primitive.E{Key: "_id", Value: item.ID}}).
+				SetUpdate(bson.D{primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "slot", Value: model.GetQueueSlot(item.UserID)}}}})
+		}
+		_, err = queueData.BulkWrite(models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return errors.WrapErrorAction(logutils.ActionUpdate, "queue data", nil, err)
+		}
+		updated += len(items)
 	}
 
-	fmt.Println("queue set to ready")
+	fmt.Printf("queue data items without slot updated: %d\n", updated)
 	return nil
 }
 
@@ -289,6 +337,12 @@ func (m *database) applyMessagesChecks(messages *collectionWrapper) error {
 		return err
 	}
 
+	//add collapse key index
+	err = messages.AddIndex(bson.D{primitive.E{Key: "collapse_key", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
 	log.Println("apply messages passed")
 	return nil
 }
@@ -352,12 +406,33 @@ func (m *database) applyQueueDataChecks(queueData *collectionWrapper) error {
 		return err
 	}
 
+	//add collapse key index
+	err = queueData.AddIndex(bson.D{primitive.E{Key: "collapse_key", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	//add priority + time index - for delivering the due items in priority order
+	err = queueData.AddIndex(bson.D{
+		{Key: "priority", Value: -1},
+		{Key: "time", Value: 1},
+	}, false)
+	if err != nil {
+		return err
+	}
+
 	//add user id index - recomended by Atlas
 	err = queueData.AddIndex(bson.D{primitive.E{Key: "user_id", Value: 1}}, false)
 	if err != nil {
 		return err
 	}
 
+	//add slot index - for loading the items of a partition
+	err = queueData.AddIndex(bson.D{primitive.E{Key: "slot", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
 	// compound index - app_id + org_id + user_id - recomended by Atlas
 	err = queueData.AddIndex(bson.D{
 		{Key: "app_id", Value: 1},
@@ -372,6 +447,114 @@ func (m *database) applyQueueDataChecks(queueData *collectionWrapper) error {
 	return nil
 }
 
+func (m *database) applyDeliveryAttemptsChecks(deliveryAttempts *collectionWrapper) error {
+	log.Println("apply delivery attempts checks.....")
+
+	//add message id index
+	err := deliveryAttempts.AddIndex(bson.D{primitive.E{Key: "message_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	//add user id index
+	err = deliveryAttempts.AddIndex(bson.D{primitive.E{Key: "user_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	//add date created index
+	err = deliveryAttempts.AddIndex(bson.D{primitive.E{Key: "date_created", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("apply delivery attempts passed")
+	return nil
+}
+
+func (m *database) applyQueuePausesChecks(queuePauses *collectionWrapper) error {
+	log.Println("apply queue pauses checks.....")
+
+	//add compound unique index - org_id + app_id
+	err := queuePauses.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}}, true)
+	if err != nil {
+		return err
+	}
+
+	log.Println("apply queue pauses passed")
+	return nil
+}
+
+func (m *database) applyQueueDeadLettersChecks(queueDeadLetters *collectionWrapper) error {
+	log.Println("apply queue dead letters checks.....")
+
+	//add compound index - org_id + app_id + message_id
+	err := queueDeadLetters.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "message_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	//add date created index
+	err = queueDeadLetters.AddIndex(bson.D{primitive.E{Key: "date_created", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("apply queue dead letters passed")
+	return nil
+}
+
+func (m *database) applyTokenPrunesChecks(tokenPrunes *collectionWrapper) error {
+	log.Println("apply token prunes checks.....")
+
+	//add compound index - org_id + app_id + user_id
+	err := tokenPrunes.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "user_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	//add token index
+	err = tokenPrunes.AddIndex(bson.D{primitive.E{Key: "token", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("apply token prunes passed")
+	return nil
+}
+
+func (m *database) applyMessageSchedulesChecks(messageSchedules *collectionWrapper) error {
+	log.Println("apply message schedules checks.....")
+
+	//add compound index - org_id + app_id
+	err := messageSchedules.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	//add compound index - status + next_run_at
+	err = messageSchedules.AddIndex(bson.D{primitive.E{Key: "status", Value: 1}, primitive.E{Key: "next_run_at", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("apply message schedules passed")
+	return nil
+}
+
+func (m *database) applyMessageCancellationsChecks(messageCancellations *collectionWrapper) error {
+	log.Println("apply message cancellations checks.....")
+
+	//add compound index - org_id + app_id + message_id
+	err := messageCancellations.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "message_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("apply message cancellations passed")
+	return nil
+}
+
 func (m *database) applyUsersChecks(users *collectionWrapper) error {
 	log.Println("apply users checks.....")
 
@@ -568,9 +751,14 @@ func (m *database) onDataChanged(changeDoc map[string]interface{}) {
 			go listener.OnFirebaseConfigurationsUpdated()
 		}
 	case "queue_data":
-		//for now
-		//m.logger.Info("queue_data collection changed")
-		//m.logger.Info(operationType)
+		if changeDoc["operationType"] != "insert" {
+			return
+		}
+
+		//the listeners debounce the events, so they are not notified in separate goroutines
+		for _, listener := range m.listeners {
+			listener.OnQueueDataInserted()
+		}
 	}
 
 }
@@ -16,6 +16,8 @@ package web
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -41,6 +43,8 @@ type Adapter struct {
 	host string
 	port string
 
+	server *http.Server
+
 	auth *Auth
 
 	cachedYamlDoc []byte
@@ -49,6 +53,7 @@ type Adapter struct {
 	adminApisHandler    AdminApisHandler
 	internalApisHandler InternalApisHandler
 	bbsApisHandler      BBsAPIsHandler
+	systemApisHandler   SystemApisHandler
 
 	app *core.Application
 
@@ -114,7 +119,26 @@ func (we Adapter) Start() {
 	adminRouter.HandleFunc("/message", we.wrapFunc(we.adminApisHandler.UpdateMessage, we.auth.admin.Permissions)).Methods("PUT")
 	adminRouter.HandleFunc("/message/{id}", we.wrapFunc(we.adminApisHandler.GetMessage, we.auth.admin.Permissions)).Methods("GET")
 	adminRouter.HandleFunc("/message/{id}", we.wrapFunc(we.adminApisHandler.DeleteMessage, we.auth.admin.Permissions)).Methods("DELETE")
+	adminRouter.HandleFunc("/message/{id}/reschedule", we.wrapFunc(we.adminApisHandler.RescheduleMessage, we.auth.admin.Permissions)).Methods("PUT")
+	adminRouter.HandleFunc("/message/{id}/cancel", we.wrapFunc(we.adminApisHandler.CancelMessage, we.auth.admin.Permissions)).Methods("POST")
+	adminRouter.HandleFunc("/messages/cancellations", we.wrapFunc(we.adminApisHandler.GetMessageCancellations, we.auth.admin.Permissions)).Methods("GET")
 	adminRouter.HandleFunc("/messages/stats/source/{source}", we.wrapFunc(we.adminApisHandler.GetMessagesStats, we.auth.admin.Permissions)).Methods("GET")
+	adminRouter.HandleFunc("/delivery-attempts", we.wrapFunc(we.adminApisHandler.GetDeliveryAttempts, we.auth.admin.Permissions)).Methods("GET")
+	adminRouter.HandleFunc("/queue", we.wrapFunc(we.adminApisHandler.GetQueue, we.auth.admin.Permissions)).Methods("GET")
+	adminRouter.HandleFunc("/queue/pause", we.wrapFunc(we.adminApisHandler.PauseQueue, we.auth.admin.Permissions)).Methods("PUT")
+	adminRouter.HandleFunc("/queue/resume", we.wrapFunc(we.adminApisHandler.ResumeQueue, we.auth.admin.Permissions)).Methods("PUT")
+	adminRouter.HandleFunc("/dead-letters", we.wrapFunc(we.adminApisHandler.GetQueueDeadLetters, we.auth.admin.Permissions)).Methods("GET")
+	adminRouter.HandleFunc("/dead-letters", we.wrapFunc(we.adminApisHandler.DeleteQueueDeadLetters, we.auth.admin.Permissions)).Methods("DELETE")
+	adminRouter.HandleFunc("/dead-letters/replay", we.wrapFunc(we.adminApisHandler.ReplayQueueDeadLetters, we.auth.admin.Permissions)).Methods("POST")
+	adminRouter.HandleFunc("/dead-letters/{id}", we.wrapFunc(we.adminApisHandler.GetQueueDeadLetter, we.auth.admin.Permissions)).Methods("GET")
+	adminRouter.HandleFunc("/dead-letters/{id}", we.wrapFunc(we.adminApisHandler.DeleteQueueDeadLetter, we.auth.admin.Permissions)).Methods("DELETE")
+	adminRouter.HandleFunc("/dead-letters/{id}/replay", we.wrapFunc(we.adminApisHandler.ReplayQueueDeadLetter, we.auth.admin.Permissions)).Methods("POST")
+	adminRouter.HandleFunc("/schedules", we.wrapFunc(we.adminApisHandler.GetMessageSchedules, we.auth.admin.Permissions)).Methods("GET")
+	adminRouter.HandleFunc("/schedules", we.wrapFunc(we.adminApisHandler.CreateMessageSchedule, we.auth.admin.Permissions)).Methods("POST")
+	adminRouter.HandleFunc("/schedules/{id}", we.wrapFunc(we.adminApisHandler.GetMessageSchedule, we.auth.admin.Permissions)).Methods("GET")
+	adminRouter.HandleFunc("/schedules/{id}", we.wrapFunc(we.adminApisHandler.DeleteMessageSchedule, we.auth.admin.Permissions)).Methods("DELETE")
+	adminRouter.HandleFunc("/schedules/{id}/pause", we.wrapFunc(we.adminApisHandler.PauseMessageSchedule, we.auth.admin.Permissions)).Methods("PUT")
+	adminRouter.HandleFunc("/schedules/{id}/resume", we.wrapFunc(we.adminApisHandler.ResumeMessageSchedule, we.auth.admin.Permissions)).Methods("PUT")
 
 	// BB APIs
 	bbsRouter := mainRouter.PathPrefix("/bbs").Subrouter()
@@ -122,6 +146,13 @@ func (we Adapter) Start() {
 	bbsRouter.HandleFunc("/messages", we.wrapFunc(we.bbsApisHandler.DeleteMessages, we.auth.bbs.Permissions)).Methods("DELETE")
 	bbsRouter.HandleFunc("/messages/{message-id}/recipients", we.wrapFunc(we.bbsApisHandler.AddRecipients, we.auth.bbs.Permissions)).Methods("POST")
 	bbsRouter.HandleFunc("/messages/{message-id}/recipients", we.wrapFunc(we.bbsApisHandler.DeleteRecipients, we.auth.bbs.Permissions)).Methods("DELETE")
+	bbsRouter.HandleFunc("/messages/{message-id}/reschedule", we.wrapFunc(we.bbsApisHandler.RescheduleMessage, we.auth.bbs.Permissions)).Methods("PUT")
+	bbsRouter.HandleFunc("/messages/{message-id}/cancel", we.wrapFunc(we.bbsApisHandler.CancelMessage, we.auth.bbs.Permissions)).Methods("POST")
+	bbsRouter.HandleFunc("/schedules", we.wrapFunc(we.bbsApisHandler.CreateMessageSchedule, we.auth.bbs.Permissions)).Methods("POST")
+	bbsRouter.HandleFunc("/schedules/{id}", we.wrapFunc(we.bbsApisHandler.GetMessageSchedule, we.auth.bbs.Permissions)).Methods("GET")
+	bbsRouter.HandleFunc("/schedules/{id}", we.wrapFunc(we.bbsApisHandler.DeleteMessageSchedule, we.auth.bbs.Permissions)).Methods("DELETE")
+	bbsRouter.HandleFunc("/schedules/{id}/pause", we.wrapFunc(we.bbsApisHandler.PauseMessageSchedule, we.auth.bbs.Permissions)).Methods("PUT")
+	bbsRouter.HandleFunc("/schedules/{id}/resume", we.wrapFunc(we.bbsApisHandler.ResumeMessageSchedule, we.auth.bbs.Permissions)).Methods("PUT")
 
 	//deprecated
 	bbsRouter.HandleFunc("/message", we.wrapFunc(we.bbsApisHandler.SendMessage, we.auth.bbs.Permissions)).Methods("POST")
@@ -130,7 +161,24 @@ func (we Adapter) Start() {
 
 	bbsRouter.HandleFunc("/mail", we.wrapFunc(we.bbsApisHandler.SendMail, we.auth.bbs.Permissions)).Methods("POST")
 
-	log.Fatal(http.ListenAndServe(":"+we.port, router))
+	// System APIs
+	systemRouter := mainRouter.PathPrefix("/system").Subrouter()
+	systemRouter.HandleFunc("/queue", we.wrapFunc(we.systemApisHandler.GetQueue, we.auth.system.Permissions)).Methods("GET")
+	systemRouter.HandleFunc("/queue", we.wrapFunc(we.systemApisHandler.UpdateQueue, we.auth.system.Permissions)).Methods("PUT")
+	systemRouter.HandleFunc("/queue/process", we.wrapFunc(we.systemApisHandler.ProcessQueue, we.auth.system.Permissions)).Methods("POST")
+	systemRouter.HandleFunc("/queue/leases", we.wrapFunc(we.systemApisHandler.GetQueueLeases, we.auth.system.Permissions)).Methods("GET")
+	systemRouter.HandleFunc("/queue/partitions/{id}", we.wrapFunc(we.systemApisHandler.UpdateQueuePartition, we.auth.system.Permissions)).Methods("PUT")
+
+	we.server.Handler = router
+	err := we.server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+// Stop stops accepting new requests and waits for the active ones until the context is done
+func (we Adapter) Stop(ctx context.Context) error {
+	return we.server.Shutdown(ctx)
 }
 
 func (we Adapter) serveDoc(w http.ResponseWriter, r *http.Request) {
@@ -222,9 +270,11 @@ func NewWebAdapter(host string, port string, app *core.Application, config *mode
 	adminApisHandler := NewAdminApisHandler(app)
 	internalApisHandler := NewInternalApisHandler(app)
 	bbsApisHandler := NewBBsAPIsHandler(app)
-	return Adapter{host: host, port: port, cachedYamlDoc: yamlDoc, auth: auth, apisHandler: apisHandler,
+	systemApisHandler := NewSystemApisHandler(app)
+	server := &http.Server{Addr: ":" + port}
+	return Adapter{host: host, port: port, server: server, cachedYamlDoc: yamlDoc, auth: auth, apisHandler: apisHandler,
 		adminApisHandler: adminApisHandler, internalApisHandler: internalApisHandler, bbsApisHandler: bbsApisHandler,
-		app: app, logger: logger}
+		systemApisHandler: systemApisHandler, app: app, logger: logger}
 }
 
 // AppListener implements core.ApplicationListener interface
@@ -264,6 +264,114 @@ func (h AdminApisHandler) DeleteMessage(l *logs.Log, r *http.Request, claims *to
 	return l.HTTPResponseSuccess()
 }
 
+// RescheduleMessage Moves a pending message to a new time
+// @Description Moves a pending message and its notifications to a new time. The expiration time is moved too.
+// @Tags Admin
+// @ID AdminRescheduleMessage
+// @Accept  json
+// @Param id path string true "id"
+// @Param data body Def.SharedReqRescheduleMessage true "body json"
+// @Success 200 {object} model.Message
+// @Security AdminUserAuth
+// @Router /admin/message/{id}/reschedule [put]
+func (h AdminApisHandler) RescheduleMessage(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	var bodyData Def.SharedReqRescheduleMessage
+	err := json.NewDecoder(r.Body).Decode(&bodyData)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDecode, logutils.TypeRequestBody, nil, err, http.StatusBadRequest, true)
+	}
+	if bodyData.Time <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "time", nil, nil, http.StatusBadRequest, false)
+	}
+
+	message, err := h.app.Admin.AdminRescheduleMessage(claims.OrgID, claims.AppID, id, time.Unix(bodyData.Time, 0))
+	if errors.Is(err, core.ErrMessageNotPending) {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "message", &logutils.FieldArgs{"id": id}, err, http.StatusConflict, false)
+	}
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "message", nil, err, http.StatusInternalServerError, true)
+	}
+	if message == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// CancelMessage Cancels a pending message
+// @Description Removes a pending message together with its recipients and notifications. A cancellation record of the message is kept.
+// @Tags Admin
+// @ID AdminCancelMessage
+// @Param id path string true "id"
+// @Success 200 {object} model.MessageCancellation
+// @Security AdminUserAuth
+// @Router /admin/message/{id}/cancel [post]
+func (h AdminApisHandler) CancelMessage(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	cancelledBy := model.Sender{Type: "administrative", User: &model.CoreAccountRef{UserID: claims.Subject, Name: claims.Name}}
+	cancellation, err := h.app.Admin.AdminCancelMessage(claims.OrgID, claims.AppID, id, cancelledBy)
+	if errors.Is(err, core.ErrMessageNotPending) {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "message", &logutils.FieldArgs{"id": id}, err, http.StatusConflict, false)
+	}
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDelete, "message", nil, err, http.StatusInternalServerError, true)
+	}
+	if cancellation == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(cancellation)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// GetMessageCancellations Gets the message cancellation records
+// @Description Gets the records of the pending messages which have been cancelled
+// @Tags Admin
+// @ID AdminGetMessageCancellations
+// @Param message_id query string false "message_id - filter by message"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
+// @Success 200 {array} model.MessageCancellation
+// @Security AdminUserAuth
+// @Router /admin/messages/cancellations [get]
+func (h AdminApisHandler) GetMessageCancellations(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	messageID := getStringQueryParam(r, "message_id")
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
+
+	cancellations, err := h.app.Admin.AdminGetMessageCancellations(claims.OrgID, claims.AppID, messageID, offset, limit)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "message cancellations", nil, err, http.StatusInternalServerError, true)
+	}
+	if cancellations == nil {
+		cancellations = []model.MessageCancellation{}
+	}
+
+	data, err := json.Marshal(cancellations)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
 // GetAllAppVersions Gets all available app versions
 // @Description Gets all available app versions
 // @Tags Admin
@@ -382,3 +490,442 @@ func (h AdminApisHandler) GetMessagesStats(l *logs.Log, r *http.Request, claims
 	}
 	return l.HTTPResponseSuccessJSON(data)
 }
+
+// GetDeliveryAttempts Gets the delivery attempts for a message or an user
+// @Description Gets the delivery attempts for a message or an user
+// @Tags Admin
+// @ID AdminGetDeliveryAttempts
+// @Param message_id query string false "message_id - filter by message"
+// @Param user_id query string false "user_id - filter by user"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
+// @Success 200 {array} model.DeliveryAttempt
+// @Security AdminUserAuth
+// @Router /admin/delivery-attempts [get]
+func (h AdminApisHandler) GetDeliveryAttempts(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	messageID := getStringQueryParam(r, "message_id")
+	userID := getStringQueryParam(r, "user_id")
+	if messageID == nil && userID == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypeQueryParam, logutils.StringArgs("message_id or user_id"), nil, http.StatusBadRequest, false)
+	}
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
+
+	attempts, err := h.app.Admin.AdminGetDeliveryAttempts(claims.OrgID, claims.AppID, messageID, userID, offset, limit)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "delivery attempts", nil, err, http.StatusInternalServerError, true)
+	}
+	if attempts == nil {
+		attempts = []model.DeliveryAttempt{}
+	}
+
+	data, err := json.Marshal(attempts)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// GetQueue Gets the queue state
+// @Description Gets whether the queue processing is paused for the org/app and the pending org/app items per message
+// @Tags Admin
+// @ID AdminGetQueue
+// @Param message_id query string false "message_id - filter the pending items by message"
+// @Success 200 {object} model.QueueOverview
+// @Security AdminUserAuth
+// @Router /admin/queue [get]
+func (h AdminApisHandler) GetQueue(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	messageID := getStringQueryParam(r, "message_id")
+
+	overview, err := h.app.Admin.AdminGetQueue(claims.OrgID, claims.AppID, messageID)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "queue", nil, err, http.StatusInternalServerError, true)
+	}
+	if overview.Pending.Messages == nil {
+		overview.Pending.Messages = []model.QueueMessagePending{}
+	}
+
+	data, err := json.Marshal(overview)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// PauseQueue Pauses the queue processing
+// @Description Pauses the queue processing for the org/app, its items stay in the queue until the processing is resumed
+// @Tags Admin
+// @ID AdminPauseQueue
+// @Success 200 {object} model.QueuePause
+// @Security AdminUserAuth
+// @Router /admin/queue/pause [put]
+func (h AdminApisHandler) PauseQueue(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	pause, err := h.app.Admin.AdminPauseQueue(claims.OrgID, claims.AppID, claims.Subject)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "queue", nil, err, http.StatusInternalServerError, true)
+	}
+
+	data, err := json.Marshal(pause)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// ResumeQueue Resumes the queue processing
+// @Description Resumes the queue processing for the org/app, the items which have become due in the meantime are sent right away
+// @Tags Admin
+// @ID AdminResumeQueue
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/queue/resume [put]
+func (h AdminApisHandler) ResumeQueue(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	resumed, err := h.app.Admin.AdminResumeQueue(claims.OrgID, claims.AppID)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "queue", nil, err, http.StatusInternalServerError, true)
+	}
+	if !resumed {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "queue pause", &logutils.FieldArgs{"org_id": claims.OrgID, "app_id": claims.AppID}, nil, http.StatusNotFound, false)
+	}
+	return l.HTTPResponseSuccess()
+}
+
+// GetQueueDeadLetters Gets the queue dead letters
+// @Description Gets the queue items which could not be delivered
+// @Tags Admin
+// @ID AdminGetQueueDeadLetters
+// @Param message_id query string false "message_id - filter by message"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
+// @Success 200 {array} model.QueueDeadLetter
+// @Security AdminUserAuth
+// @Router /admin/dead-letters [get]
+func (h AdminApisHandler) GetQueueDeadLetters(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	messageID := getStringQueryParam(r, "message_id")
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
+
+	deadLetters, err := h.app.Admin.AdminGetQueueDeadLetters(claims.OrgID, claims.AppID, messageID, offset, limit)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "queue dead letters", nil, err, http.StatusInternalServerError, true)
+	}
+	if deadLetters == nil {
+		deadLetters = []model.QueueDeadLetter{}
+	}
+
+	data, err := json.Marshal(deadLetters)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// GetQueueDeadLetter Gets a queue dead letter by id
+// @Description Gets a queue dead letter by id
+// @Tags Admin
+// @ID AdminGetQueueDeadLetter
+// @Param id path string true "id"
+// @Success 200 {object} model.QueueDeadLetter
+// @Security AdminUserAuth
+// @Router /admin/dead-letters/{id} [get]
+func (h AdminApisHandler) GetQueueDeadLetter(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	deadLetter, err := h.app.Admin.AdminGetQueueDeadLetter(claims.OrgID, claims.AppID, id)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "queue dead letter", nil, err, http.StatusInternalServerError, true)
+	}
+	if deadLetter == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "queue dead letter", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(deadLetter)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// ReplayQueueDeadLetters Puts back in the queue the dead letters
+// @Description Puts back in the queue the dead letters for the app or only the ones for a message
+// @Tags Admin
+// @ID AdminReplayQueueDeadLetters
+// @Param message_id query string false "message_id - replay only the dead letters for a message"
+// @Param limit query string false "limit - the max number of dead letters to replay"
+// @Success 200 {object} Def.AdminResReplayQueueDeadLetters
+// @Security AdminUserAuth
+// @Router /admin/dead-letters/replay [post]
+func (h AdminApisHandler) ReplayQueueDeadLetters(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	messageID := getStringQueryParam(r, "message_id")
+	limit := getInt64QueryParam(r, "limit")
+	if limit != nil && *limit <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, logutils.TypeQueryParam, logutils.StringArgs("limit"), nil, http.StatusBadRequest, false)
+	}
+
+	replayed, err := h.app.Admin.AdminReplayQueueDeadLetters(claims.OrgID, claims.AppID, nil, messageID, limit)
+	if err != nil {
+		return l.HTTPResponseErrorAction("replaying", "queue dead letters", nil, err, http.StatusInternalServerError, true)
+	}
+
+	data, err := json.Marshal(Def.AdminResReplayQueueDeadLetters{Replayed: replayed})
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// ReplayQueueDeadLetter Puts back in the queue a dead letter
+// @Description Puts back in the queue a dead letter
+// @Tags Admin
+// @ID AdminReplayQueueDeadLetter
+// @Param id path string true "id"
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/dead-letters/{id}/replay [post]
+func (h AdminApisHandler) ReplayQueueDeadLetter(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	replayed, err := h.app.Admin.AdminReplayQueueDeadLetters(claims.OrgID, claims.AppID, &id, nil, nil)
+	if err != nil {
+		return l.HTTPResponseErrorAction("replaying", "queue dead letter", nil, err, http.StatusInternalServerError, true)
+	}
+	if replayed == 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "queue dead letter", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+	return l.HTTPResponseSuccess()
+}
+
+// DeleteQueueDeadLetters Purges the dead letters
+// @Description Purges the dead letters for the app or only the ones for a message
+// @Tags Admin
+// @ID AdminDeleteQueueDeadLetters
+// @Param message_id query string false "message_id - purge only the dead letters for a message"
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/dead-letters [delete]
+func (h AdminApisHandler) DeleteQueueDeadLetters(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	messageID := getStringQueryParam(r, "message_id")
+
+	_, err := h.app.Admin.AdminDeleteQueueDeadLetters(claims.OrgID, claims.AppID, nil, messageID)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDelete, "queue dead letters", nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccess()
+}
+
+// DeleteQueueDeadLetter Deletes a dead letter
+// @Description Deletes a dead letter
+// @Tags Admin
+// @ID AdminDeleteQueueDeadLetter
+// @Param id path string true "id"
+// @Success 200
+// @Security AdminUserAuth
+// @Router /admin/dead-letters/{id} [delete]
+func (h AdminApisHandler) DeleteQueueDeadLetter(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	_, err := h.app.Admin.AdminDeleteQueueDeadLetters(claims.OrgID, claims.AppID, &id, nil)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDelete, "queue dead letter", nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccess()
+}
+
+// CreateMessageSchedule Creates a message schedule
+// @Description Creates a recurring message schedule. A new message and its recipients are created on every run of the cron expression.
+// @Tags Admin
+// @ID AdminCreateMessageSchedule
+// @Accept  json
+// @Param data body Def.SharedReqCreateMessageSchedule true "body json"
+// @Success 200 {object} model.MessageSchedule
+// @Security AdminUserAuth
+// @Router /admin/schedules [post]
+func (h AdminApisHandler) CreateMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	var inputData Def.SharedReqCreateMessageSchedule
+	err := json.NewDecoder(r.Body).Decode(&inputData)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDecode, logutils.TypeRequestBody, nil, err, http.StatusBadRequest, true)
+	}
+	if len(inputData.Message.Body) == 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "body", nil, nil, http.StatusBadRequest, false)
+	}
+
+	sender := model.Sender{Type: "administrative", User: &model.CoreAccountRef{UserID: claims.Subject, Name: claims.Name}}
+	message, err := getMessageTemplateData(inputData, sender)
+	if err != nil {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "message schedule", nil, err, http.StatusBadRequest, true)
+	}
+
+	schedule, err := h.app.Admin.AdminCreateMessageSchedule(claims.OrgID, claims.AppID, inputData.Cron, inputData.TimeZone, *message)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionCreate, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// GetMessageSchedules Gets the message schedules
+// @Description Gets the recurring message schedules
+// @Tags Admin
+// @ID AdminGetMessageSchedules
+// @Param status query string false "status - active or paused"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit - limit the result"
+// @Success 200 {array} model.MessageSchedule
+// @Security AdminUserAuth
+// @Router /admin/schedules [get]
+func (h AdminApisHandler) GetMessageSchedules(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	status := getStringQueryParam(r, "status")
+	offset := getInt64QueryParam(r, "offset")
+	limit := getInt64QueryParam(r, "limit")
+
+	schedules, err := h.app.Admin.AdminGetMessageSchedules(claims.OrgID, claims.AppID, status, offset, limit)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "message schedules", nil, err, http.StatusInternalServerError, true)
+	}
+	if schedules == nil {
+		schedules = []model.MessageSchedule{}
+	}
+
+	data, err := json.Marshal(schedules)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// GetMessageSchedule Gets a message schedule by id
+// @Description Gets a message schedule by id
+// @Tags Admin
+// @ID AdminGetMessageSchedule
+// @Param id path string true "id"
+// @Success 200 {object} model.MessageSchedule
+// @Security AdminUserAuth
+// @Router /admin/schedules/{id} [get]
+func (h AdminApisHandler) GetMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	schedule, err := h.app.Admin.AdminGetMessageSchedule(claims.OrgID, claims.AppID, id)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+	if schedule == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message schedule", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// PauseMessageSchedule Pauses a message schedule
+// @Description Pauses a message schedule, no messages are created until it is resumed
+// @Tags Admin
+// @ID AdminPauseMessageSchedule
+// @Param id path string true "id"
+// @Success 200 {object} model.MessageSchedule
+// @Security AdminUserAuth
+// @Router /admin/schedules/{id}/pause [put]
+func (h AdminApisHandler) PauseMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	schedule, err := h.app.Admin.AdminPauseMessageSchedule(claims.OrgID, claims.AppID, id)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+	if schedule == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message schedule", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// ResumeMessageSchedule Resumes a paused message schedule
+// @Description Resumes a paused message schedule, it continues with its first run after now
+// @Tags Admin
+// @ID AdminResumeMessageSchedule
+// @Param id path string true "id"
+// @Success 200 {object} model.MessageSchedule
+// @Security AdminUserAuth
+// @Router /admin/schedules/{id}/resume [put]
+func (h AdminApisHandler) ResumeMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	schedule, err := h.app.Admin.AdminResumeMessageSchedule(claims.OrgID, claims.AppID, id)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+	if schedule == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message schedule", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// DeleteMessageSchedule Deletes a message schedule by id
+// @Description Deletes a message schedule by id
+// @Tags Admin
+// @ID AdminDeleteMessageSchedule
+// @Param id path string true "id"
+// @Success 200 {object} model.MessageSchedule
+// @Security AdminUserAuth
+// @Router /admin/schedules/{id} [delete]
+func (h AdminApisHandler) DeleteMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	schedule, err := h.app.Admin.AdminDeleteMessageSchedule(claims.OrgID, claims.AppID, id)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDelete, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+	if schedule == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message schedule", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
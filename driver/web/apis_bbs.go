@@ -16,11 +16,13 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"notifications/core"
 	"notifications/core/model"
 	Def "notifications/driver/web/docs/gen"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
@@ -177,6 +179,84 @@ func (h BBsAPIsHandler) DeleteMessages(l *logs.Log, r *http.Request, claims *tok
 	return l.HTTPResponseSuccess()
 }
 
+// RescheduleMessage Moves a pending message to a new time
+// @Description Moves a pending message and its notifications to a new time. The expiration time is moved too.
+// @Tags BBs
+// @ID BBsRescheduleMessage
+// @Accept  json
+// @Param message-id path string true "message-id"
+// @Param data body Def.SharedReqRescheduleMessage true "body json"
+// @Success 200 {object} model.Message
+// @Security BBsAuth
+// @Router /bbs/messages/{message-id}/reschedule [put]
+func (h BBsAPIsHandler) RescheduleMessage(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["message-id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("message-id"), nil, http.StatusBadRequest, false)
+	}
+
+	var bodyData Def.SharedReqRescheduleMessage
+	err := json.NewDecoder(r.Body).Decode(&bodyData)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDecode, logutils.TypeRequestBody, nil, err, http.StatusBadRequest, true)
+	}
+	if bodyData.Time <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "time", nil, nil, http.StatusBadRequest, false)
+	}
+
+	message, err := h.app.BBs.BBsRescheduleMessage(claims.Subject, id, time.Unix(bodyData.Time, 0))
+	if errors.Is(err, core.ErrMessageNotPending) {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "message", &logutils.FieldArgs{"id": id}, err, http.StatusConflict, false)
+	}
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "message", nil, err, http.StatusInternalServerError, true)
+	}
+	if message == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponse, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// CancelMessage Cancels a pending message
+// @Description Removes a pending message together with its recipients and notifications. A cancellation record of the message is kept.
+// @Tags BBs
+// @ID BBsCancelMessage
+// @Param message-id path string true "message-id"
+// @Success 200 {object} model.MessageCancellation
+// @Security BBsAuth
+// @Router /bbs/messages/{message-id}/cancel [post]
+func (h BBsAPIsHandler) CancelMessage(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["message-id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("message-id"), nil, http.StatusBadRequest, false)
+	}
+
+	cancelledBy := model.Sender{Type: "system", User: &model.CoreAccountRef{UserID: claims.Subject, Name: claims.Name}}
+	cancellation, err := h.app.BBs.BBsCancelMessage(claims.Subject, id, cancelledBy)
+	if errors.Is(err, core.ErrMessageNotPending) {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "message", &logutils.FieldArgs{"id": id}, err, http.StatusConflict, false)
+	}
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDelete, "message", nil, err, http.StatusInternalServerError, true)
+	}
+	if cancellation == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(cancellation)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponse, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
 // sendMailRequestBody mail request body
 type bbsSendMailRequestBody struct {
 	ToMail  string `json:"to_mail"`
@@ -269,3 +349,167 @@ func (h BBsAPIsHandler) DeleteRecipients(l *logs.Log, r *http.Request, claims *t
 	}
 	return l.HTTPResponseSuccess()
 }
+
+// CreateMessageSchedule Creates a message schedule
+// @Description Creates a recurring message schedule. A new message and its recipients are created on every run of the cron expression.
+// @Tags BBs
+// @ID BBsCreateMessageSchedule
+// @Accept  json
+// @Param data body Def.SharedReqCreateMessageSchedule true "body json"
+// @Success 200 {object} model.MessageSchedule
+// @Security BBsAuth
+// @Router /bbs/schedules [post]
+func (h BBsAPIsHandler) CreateMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	var inputData Def.SharedReqCreateMessageSchedule
+	err := json.NewDecoder(r.Body).Decode(&inputData)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDecode, logutils.TypeRequestBody, nil, err, http.StatusBadRequest, true)
+	}
+
+	orgID := inputData.Message.OrgId
+	appID := inputData.Message.AppId
+	if len(orgID) == 0 || len(appID) == 0 {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "org or app id", nil, nil, http.StatusBadRequest, false)
+	}
+
+	if !claims.AppOrg().CanAccessAppOrg(appID, orgID) {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "org or app id", nil, nil, http.StatusForbidden, false)
+	}
+
+	sender := model.Sender{Type: "system", User: &model.CoreAccountRef{UserID: claims.Subject, Name: claims.Name}}
+	message, err := getMessageTemplateData(inputData, sender)
+	if err != nil {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, "message schedule", nil, err, http.StatusBadRequest, true)
+	}
+
+	schedule, err := h.app.BBs.BBsCreateMessageSchedule(orgID, appID, inputData.Cron, inputData.TimeZone, *message)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionCreate, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponse, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// GetMessageSchedule Gets a message schedule by id
+// @Description Gets a message schedule by id
+// @Tags BBs
+// @ID BBsGetMessageSchedule
+// @Param id path string true "id"
+// @Success 200 {object} model.MessageSchedule
+// @Security BBsAuth
+// @Router /bbs/schedules/{id} [get]
+func (h BBsAPIsHandler) GetMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	schedule, err := h.app.BBs.BBsGetMessageSchedule(claims.Subject, id)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+	if schedule == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message schedule", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// PauseMessageSchedule Pauses a message schedule
+// @Description Pauses a message schedule, no messages are created until it is resumed
+// @Tags BBs
+// @ID BBsPauseMessageSchedule
+// @Param id path string true "id"
+// @Success 200 {object} model.MessageSchedule
+// @Security BBsAuth
+// @Router /bbs/schedules/{id}/pause [put]
+func (h BBsAPIsHandler) PauseMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	schedule, err := h.app.BBs.BBsPauseMessageSchedule(claims.Subject, id)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+	if schedule == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message schedule", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// ResumeMessageSchedule Resumes a paused message schedule
+// @Description Resumes a paused message schedule, it continues with its first run after now
+// @Tags BBs
+// @ID BBsResumeMessageSchedule
+// @Param id path string true "id"
+// @Success 200 {object} model.MessageSchedule
+// @Security BBsAuth
+// @Router /bbs/schedules/{id}/resume [put]
+func (h BBsAPIsHandler) ResumeMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	schedule, err := h.app.BBs.BBsResumeMessageSchedule(claims.Subject, id)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+	if schedule == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message schedule", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// DeleteMessageSchedule Deletes a message schedule by id
+// @Description Deletes a message schedule by id
+// @Tags BBs
+// @ID BBsDeleteMessageSchedule
+// @Param id path string true "id"
+// @Success 200 {object} model.MessageSchedule
+// @Security BBsAuth
+// @Router /bbs/schedules/{id} [delete]
+func (h BBsAPIsHandler) DeleteMessageSchedule(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	schedule, err := h.app.BBs.BBsDeleteMessageSchedule(claims.Subject, id)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDelete, "message schedule", nil, err, http.StatusInternalServerError, true)
+	}
+	if schedule == nil {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, "message schedule", &logutils.FieldArgs{"id": id}, nil, http.StatusNotFound, false)
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
@@ -126,7 +126,10 @@ func (h ApisHandler) GetUser(l *logs.Log, r *http.Request, claims *tokenauth.Cla
 
 // updateUserRequest Wrapper for update user request body
 type updateUserRequest struct {
-	NotificationsDisabled bool `json:"notifications_disabled" bson:"notifications_disabled"`
+	NotificationsDisabled bool                  `json:"notifications_disabled" bson:"notifications_disabled"`
+	QuietHours            *model.QuietHours     `json:"quiet_hours" bson:"quiet_hours"`
+	TimeZone              *string               `json:"time_zone" bson:"time_zone"`
+	Digest                *model.DigestSettings `json:"digest" bson:"digest"`
 } // @name updateUserRequest
 
 // UpdateUser Updates user record
@@ -144,7 +147,27 @@ func (h ApisHandler) UpdateUser(l *logs.Log, r *http.Request, claims *tokenauth.
 		return l.HTTPResponseErrorAction(logutils.ActionDecode, logutils.TypeRequestBody, nil, err, http.StatusBadRequest, true)
 	}
 
-	userMapping, err := h.app.Services.UpdateUserByID(claims.OrgID, claims.AppID, claims.Subject, bodyData.NotificationsDisabled)
+	if bodyData.QuietHours != nil {
+		err = bodyData.QuietHours.Validate()
+		if err != nil {
+			return l.HTTPResponseErrorData(logutils.StatusInvalid, "quiet hours", nil, err, http.StatusBadRequest, true)
+		}
+	}
+	if bodyData.Digest != nil {
+		err = bodyData.Digest.Validate()
+		if err != nil {
+			return l.HTTPResponseErrorData(logutils.StatusInvalid, "digest", nil, err, http.StatusBadRequest, true)
+		}
+	}
+	if bodyData.TimeZone != nil && len(*bodyData.TimeZone) > 0 {
+		_, err = time.LoadLocation(*bodyData.TimeZone)
+		if err != nil {
+			return l.HTTPResponseErrorData(logutils.StatusInvalid, "time zone", &logutils.FieldArgs{"time_zone": *bodyData.TimeZone}, err, http.StatusBadRequest, true)
+		}
+	}
+
+	userMapping, err := h.app.Services.UpdateUserByID(claims.OrgID, claims.AppID, claims.Subject, bodyData.NotificationsDisabled,
+		bodyData.QuietHours, bodyData.TimeZone, bodyData.Digest)
 	if err != nil {
 		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "user", nil, err, http.StatusInternalServerError, true)
 	}
@@ -286,9 +309,14 @@ type getUserMessageResponse struct {
 	DateCreated               *time.Time                `json:"date_created"`
 	DateUpdated               *time.Time                `json:"date_updated"`
 	Time                      time.Time                 `json:"time"`
-
-	Mute bool `json:"mute"`
-	Read bool `json:"read"`
+	ExpiresAt                 *time.Time                `json:"expires_at"`
+	CollapseKey               *string                   `json:"collapse_key"`
+	PushOptions               *model.PushOptions        `json:"push_options"`
+	DeliveryMode              string                    `json:"delivery_mode"`
+
+	Mute       bool `json:"mute"`
+	Read       bool `json:"read"`
+	Superseded bool `json:"superseded"`
 }
 
 // GetUserMessages Gets all messages for the user
@@ -300,6 +328,7 @@ func (h ApisHandler) GetUserMessages(l *logs.Log, r *http.Request, claims *token
 	endDateFilter := getInt64QueryParam(r, "end_date")
 	read := getBoolQueryParam(r, "read")
 	mute := getBoolQueryParam(r, "mute")
+	includeExpired := getBoolQueryParam(r, "include_expired")
 
 	var messageIDs []string
 	var body getMessagesRequestBody
@@ -308,21 +337,26 @@ func (h ApisHandler) GetUserMessages(l *logs.Log, r *http.Request, claims *token
 		messageIDs = body.IDs
 	}
 
-	recipientsMessages, err := h.app.Services.GetMessagesRecipientsDeep(claims.OrgID, claims.AppID, &claims.Subject, read, mute, messageIDs, startDateFilter, endDateFilter, nil, offsetFilter, limitFilter, orderFilter)
+	recipientsMessages, err := h.app.Services.GetMessagesRecipientsDeep(claims.OrgID, claims.AppID, &claims.Subject, read, mute, messageIDs, startDateFilter, endDateFilter, nil,
+		includeExpired != nil && *includeExpired, offsetFilter, limitFilter, orderFilter)
 	if err != nil {
 		return l.HTTPResponseErrorAction(logutils.ActionGet, "messages", nil, err, http.StatusInternalServerError, true)
 	}
+	locales := getAcceptLanguages(r)
 	result := make([]getUserMessageResponse, len(recipientsMessages))
 	for i, item := range recipientsMessages {
 		message := item.Message
+		subject, body := message.GetLocalizedContent(locales) //in the caller language
 
 		respItem := getUserMessageResponse{OrgID: message.OrgID, AppID: message.AppID,
-			ID: message.ID, Priority: message.Priority, Subject: message.Subject,
-			Sender: message.Sender, Body: message.Body, Data: message.Data, Recipients: message.Recipients,
+			ID: message.ID, Priority: message.Priority, Subject: subject,
+			Sender: message.Sender, Body: body, Data: message.Data, Recipients: message.Recipients,
 			RecipientsCriteriaList: message.RecipientsCriteriaList, RecipientAccountCriteria: message.RecipientAccountCriteria,
 			Topic: message.Topic, CalculatedRecipientsCount: message.CalculatedRecipientsCount,
 			DateCreated: message.DateCreated, DateUpdated: message.DateUpdated,
-			Mute: item.Mute, Read: item.Read, Time: message.Time}
+			Mute: item.Mute, Read: item.Read, Superseded: item.Superseded, Time: message.Time, ExpiresAt: message.ExpiresAt,
+			CollapseKey: message.CollapseKey, PushOptions: message.PushOptions,
+			DeliveryMode: message.DeliveryMode}
 		result[i] = respItem
 	}
 	data, err := json.Marshal(result)
@@ -439,6 +473,9 @@ func (h ApisHandler) GetUserMessage(l *logs.Log, r *http.Request, claims *tokena
 	if err != nil {
 		return l.HTTPResponseErrorAction(logutils.ActionGet, "message", nil, err, http.StatusInternalServerError, true)
 	}
+	if message != nil {
+		message.Subject, message.Body = message.GetLocalizedContent(getAcceptLanguages(r)) //in the caller language
+	}
 
 	data, err := json.Marshal(message)
 	if err != nil {
@@ -0,0 +1,155 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"notifications/core"
+	"notifications/core/model"
+	Def "notifications/driver/web/docs/gen"
+
+	"github.com/gorilla/mux"
+	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/tokenauth"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logs"
+	"github.com/rokwire/rokwire-building-block-sdk-go/utils/logging/logutils"
+)
+
+// SystemApisHandler handles the rest System APIs implementation
+type SystemApisHandler struct {
+	app *core.Application
+}
+
+// NewSystemApisHandler creates new rest Handler instance
+func NewSystemApisHandler(app *core.Application) SystemApisHandler {
+	return SystemApisHandler{app: app}
+}
+
+// GetQueue Gets the whole queue state
+// @Description Gets the queue partitions with their lock state and batch size and the next time the queue is processed
+// @Tags System
+// @ID SystemGetQueue
+// @Success 200 {object} model.QueueState
+// @Security AdminUserAuth
+// @Router /system/queue [get]
+func (h SystemApisHandler) GetQueue(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	state, err := h.app.System.SystemGetQueue()
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "queue", nil, err, http.StatusInternalServerError, true)
+	}
+	if state.Partitions == nil {
+		state.Partitions = []model.Queue{}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// GetQueueLeases Gets the queue lease state
+// @Description Gets the queue records together with their lease state - which instance processes the queue and until when
+// @Tags System
+// @ID SystemGetQueueLeases
+// @Success 200 {array} model.Queue
+// @Security AdminUserAuth
+// @Router /system/queue/leases [get]
+func (h SystemApisHandler) GetQueueLeases(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	queues, err := h.app.System.SystemGetQueueLeases()
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionGet, "queue leases", nil, err, http.StatusInternalServerError, true)
+	}
+	if queues == nil {
+		queues = []model.Queue{}
+	}
+
+	data, err := json.Marshal(queues)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionMarshal, logutils.TypeResponseBody, nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccessJSON(data)
+}
+
+// UpdateQueuePartition Updates a queue partition
+// @Description Sets how many items are loaded at once for a queue partition
+// @Tags System
+// @ID SystemUpdateQueuePartition
+// @Param id path string true "id"
+// @Param data body Def.SystemReqUpdateQueuePartition true "body data"
+// @Accept  json
+// @Success 200
+// @Security AdminUserAuth
+// @Router /system/queue/partitions/{id} [put]
+func (h SystemApisHandler) UpdateQueuePartition(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	params := mux.Vars(r)
+	id := params["id"]
+	if len(id) <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusMissing, logutils.TypePathParam, logutils.StringArgs("id"), nil, http.StatusBadRequest, false)
+	}
+
+	var bodyData Def.SystemReqUpdateQueuePartition
+	err := json.NewDecoder(r.Body).Decode(&bodyData)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDecode, logutils.TypeRequestBody, nil, err, http.StatusBadRequest, true)
+	}
+	if bodyData.ProcessItemsCount <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, logutils.TypeRequestBody, logutils.StringArgs("process_items_count"), nil, http.StatusBadRequest, false)
+	}
+
+	err = h.app.System.SystemUpdateQueuePartition(id, bodyData.ProcessItemsCount)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "queue partition", nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccess()
+}
+
+// UpdateQueue Updates the queue
+// @Description Sets how many items are loaded at once for all queue partitions
+// @Tags System
+// @ID SystemUpdateQueue
+// @Param data body Def.SystemReqUpdateQueue true "body data"
+// @Accept  json
+// @Success 200
+// @Security AdminUserAuth
+// @Router /system/queue [put]
+func (h SystemApisHandler) UpdateQueue(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	var bodyData Def.SystemReqUpdateQueue
+	err := json.NewDecoder(r.Body).Decode(&bodyData)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionDecode, logutils.TypeRequestBody, nil, err, http.StatusBadRequest, true)
+	}
+	if bodyData.ProcessItemsCount <= 0 {
+		return l.HTTPResponseErrorData(logutils.StatusInvalid, logutils.TypeRequestBody, logutils.StringArgs("process_items_count"), nil, http.StatusBadRequest, false)
+	}
+
+	err = h.app.System.SystemUpdateQueue(bodyData.ProcessItemsCount)
+	if err != nil {
+		return l.HTTPResponseErrorAction(logutils.ActionUpdate, "queue", nil, err, http.StatusInternalServerError, true)
+	}
+	return l.HTTPResponseSuccess()
+}
+
+// ProcessQueue Processes the queue
+// @Description Triggers the queue processing without waiting for the next scheduled time. The processing happens in the background.
+// @Tags System
+// @ID SystemProcessQueue
+// @Success 200
+// @Security AdminUserAuth
+// @Router /system/queue/process [post]
+func (h SystemApisHandler) ProcessQueue(l *logs.Log, r *http.Request, claims *tokenauth.Claims) logs.HTTPResponse {
+	h.app.System.SystemProcessQueue()
+	return l.HTTPResponseSuccess()
+}
@@ -32,6 +32,7 @@ type Auth struct {
 	client   tokenauth.Handlers
 	admin    tokenauth.Handlers
 	bbs      tokenauth.Handlers
+	system   tokenauth.Handlers
 	internal InternalAuth
 }
 
@@ -55,12 +56,19 @@ func NewAuth(app *core.Application, config *model.Config, serviceRegManager *aut
 	}
 	bbsHandlers := tokenauth.NewHandlers(bbs)
 
+	system, err := newSystemAuth(serviceRegManager)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionCreate, "system auth", nil, err)
+	}
+	systemHandlers := tokenauth.NewHandlers(system)
+
 	internal := newInternalAuth(config.InternalAPIKey)
 
 	auth := Auth{
 		client:   clientHandlers,
 		admin:    adminHandlers,
 		bbs:      bbsHandlers,
+		system:   systemHandlers,
 		internal: internal,
 	}
 	return &auth, nil
@@ -217,3 +225,41 @@ func newBBsAuth(serviceRegManager *auth.ServiceRegManager) (*BBsAuth, error) {
 	auth := BBsAuth{tokenAuth: bbsTokenAuth}
 	return &auth, nil
 }
+
+// SystemAuth entity
+type SystemAuth struct {
+	tokenAuth *tokenauth.TokenAuth
+}
+
+// Check validates the request contains a valid system admin token
+func (auth SystemAuth) Check(req *http.Request) (int, *tokenauth.Claims, error) {
+	claims, err := auth.tokenAuth.CheckRequestToken(req)
+	if err != nil {
+		return http.StatusUnauthorized, nil, errors.WrapErrorAction(logutils.ActionValidate, logutils.TypeToken, nil, err)
+	}
+
+	if !claims.Admin {
+		return http.StatusUnauthorized, nil, errors.ErrorData(logutils.StatusInvalid, "admin claim", nil)
+	}
+	if !claims.System {
+		return http.StatusUnauthorized, nil, errors.ErrorData(logutils.StatusInvalid, "system claim", nil)
+	}
+
+	return http.StatusOK, claims, nil
+}
+
+// GetTokenAuth returns the TokenAuth from the handler
+func (auth SystemAuth) GetTokenAuth() *tokenauth.TokenAuth {
+	return auth.tokenAuth
+}
+
+func newSystemAuth(serviceRegManager *auth.ServiceRegManager) (*SystemAuth, error) {
+	systemPermissionAuth := authorization.NewCasbinStringAuthorization("driver/web/system_permission_policy.csv")
+	systemTokenAuth, err := tokenauth.NewTokenAuth(true, serviceRegManager, systemPermissionAuth, nil)
+	if err != nil {
+		return nil, errors.WrapErrorAction(logutils.ActionCreate, "system token auth", nil, err)
+	}
+
+	auth := SystemAuth{tokenAuth: systemTokenAuth}
+	return &auth, nil
+}
@@ -19,7 +19,10 @@ import (
 	"net/http"
 	"notifications/core/model"
 	Def "notifications/driver/web/docs/gen"
+	"notifications/utils"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -54,12 +57,55 @@ func getBoolQueryParam(r *http.Request, paramName string) *bool {
 	return nil
 }
 
+// getAcceptLanguages gives the locales from the Accept-Language header in order of preference, for example "es-MX,es;q=0.9,en;q=0.8"
+func getAcceptLanguages(r *http.Request) []string {
+	type language struct {
+		locale  string
+		quality float64
+	}
+
+	languages := []language{}
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		locale, params, _ := strings.Cut(part, ";")
+		locale = strings.TrimSpace(locale)
+		if len(locale) == 0 || locale == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue //not acceptable
+		}
+		languages = append(languages, language{locale: locale, quality: quality})
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].quality > languages[j].quality })
+
+	result := make([]string, len(languages))
+	for i, current := range languages {
+		result[i] = current.locale
+	}
+	return result
+}
+
 func getMessageData(inputMessage Def.SharedReqCreateMessage) model.InputMessage {
 	mTime := time.Now()
 	if inputMessage.Time != nil {
 		mTime = time.Unix(*inputMessage.Time, 0)
 	}
 
+	var expiresAt *time.Time
+	if inputMessage.ExpiresAt != nil {
+		expiresAtValue := time.Unix(*inputMessage.ExpiresAt, 0)
+		expiresAt = &expiresAtValue
+	}
+
 	priority := inputMessage.Priority
 	subject := inputMessage.Subject
 	body := inputMessage.Body
@@ -71,8 +117,34 @@ func getMessageData(inputMessage Def.SharedReqCreateMessage) model.InputMessage
 	recipientsCriteria := recipientsCriteriaListFromDef(inputMessage.RecipientsCriteriaList)
 	recipientsAccountCriteria := inputMessage.RecipientAccountCriteria
 	topic := inputMessage.Topic
+	collapseKey := inputMessage.CollapseKey
+	supersedePrevious := inputMessage.SupersedePrevious != nil && *inputMessage.SupersedePrevious
+	pushOptions := pushOptionsFromDef(inputMessage.PushOptions)
+	deliveryMode := utils.GetString(inputMessage.DeliveryMode)
+	silentInInbox := inputMessage.SilentInInbox != nil && *inputMessage.SilentInInbox
+	localizations := messageLocalizationsFromDef(inputMessage.Localizations)
 
-	return model.InputMessage{ID: inputMessage.Id, Time: mTime, Priority: priority, Subject: subject,
+	return model.InputMessage{ID: inputMessage.Id, Time: mTime, ExpiresAt: expiresAt, Priority: priority, Subject: subject,
 		Body: body, Data: inputData, Topic: topic, InputRecipients: inputRecipients,
-		RecipientsCriteriaList: recipientsCriteria, RecipientAccountCriteria: recipientsAccountCriteria}
+		RecipientsCriteriaList: recipientsCriteria, RecipientAccountCriteria: recipientsAccountCriteria,
+		CollapseKey: collapseKey, SupersedePrevious: supersedePrevious, PushOptions: pushOptions,
+		DeliveryMode: deliveryMode, SilentInInbox: silentInInbox, Localizations: localizations}
+}
+
+// getMessageTemplateData gives the message template of a schedule request. It validates the schedule cron expression and time zone.
+func getMessageTemplateData(inputSchedule Def.SharedReqCreateMessageSchedule, sender model.Sender) (*model.MessageTemplate, error) {
+	schedule := model.MessageSchedule{Cron: inputSchedule.Cron, TimeZone: inputSchedule.TimeZone}
+	_, err := schedule.GetNextRun(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if inputSchedule.Ttl != nil && *inputSchedule.Ttl <= 0 {
+		return nil, fmt.Errorf("invalid ttl %d", *inputSchedule.Ttl)
+	}
+
+	im := getMessageData(inputSchedule.Message)
+	return &model.MessageTemplate{Sender: sender, Priority: im.Priority, Subject: im.Subject, Body: im.Body, Data: im.Data,
+		Recipients: im.InputRecipients, RecipientsCriteriaList: im.RecipientsCriteriaList, RecipientAccountCriteria: im.RecipientAccountCriteria,
+		Topic: im.Topic, CollapseKey: im.CollapseKey, SupersedePrevious: im.SupersedePrevious, TTL: inputSchedule.Ttl,
+		PushOptions: im.PushOptions, DeliveryMode: im.DeliveryMode, SilentInInbox: im.SilentInInbox, Localizations: im.Localizations}, nil
 }
@@ -3,6 +3,7 @@ package web
 import (
 	"notifications/core/model"
 	Def "notifications/driver/web/docs/gen"
+	"notifications/utils"
 )
 
 // RecipientCriteria Type
@@ -22,3 +23,24 @@ func messagesRecipientsListFromDef(items []Def.SharedReqCreateMessageInputMessag
 	}
 	return result
 }
+
+// PushOptions Type
+func pushOptionsFromDef(item *Def.PushOptions) *model.PushOptions {
+	if item == nil {
+		return nil
+	}
+	return &model.PushOptions{Sound: item.Sound, Badge: item.Badge, ImageURL: item.ImageUrl,
+		AndroidChannelID: item.AndroidChannelId, APNSCategory: item.ApnsCategory, WebpushLink: item.WebpushLink}
+}
+
+// MessageLocalization Type
+func messageLocalizationsFromDef(items *map[string]Def.MessageLocalization) map[string]model.MessageLocalization {
+	if items == nil {
+		return nil
+	}
+	result := make(map[string]model.MessageLocalization, len(*items))
+	for locale, item := range *items {
+		result[locale] = model.MessageLocalization{Subject: utils.GetString(item.Subject), Body: utils.GetString(item.Body)}
+	}
+	return result
+}
@@ -13,25 +13,68 @@ type CoreAccountRef struct {
 	UserId *string `json:"user_id,omitempty"`
 }
 
+// DeliveryAttempt defines model for DeliveryAttempt.
+type DeliveryAttempt struct {
+	AppId              *string `json:"app_id,omitempty"`
+	AppPlatform        *string `json:"app_platform,omitempty"`
+	DateCreated        *string `json:"date_created,omitempty"`
+	Error              *string `json:"error,omitempty"`
+	ErrorCode          *string `json:"error_code,omitempty"`
+	FcmMessageId       *string `json:"fcm_message_id,omitempty"`
+	Id                 *string `json:"id,omitempty"`
+	MessageId          *string `json:"message_id,omitempty"`
+	MessageRecipientId *string `json:"message_recipient_id,omitempty"`
+	OrgId              *string `json:"org_id,omitempty"`
+	QueueItemId        *string `json:"queue_item_id,omitempty"`
+
+	// Status sent or failed
+	Status *string `json:"status,omitempty"`
+	Token  *string `json:"token,omitempty"`
+	UserId *string `json:"user_id,omitempty"`
+}
+
+// DigestSettings the low priority notifications are bundled in one summary notification per interval. The summary data has type "digest", count and messages_ids
+type DigestSettings struct {
+	// Interval minutes, the notifications are bundled for that long. Max 1440, 0 removes the digest
+	Interval *int `json:"interval,omitempty"`
+
+	// Topics only the notifications for these topics are bundled, all if empty
+	Topics *[]string `json:"topics,omitempty"`
+}
+
 // FirebaseToken defines model for FirebaseToken.
 type FirebaseToken struct {
 	AppPlatform *string `json:"app_platform,omitempty"`
 	AppVersion  *string `json:"app_version,omitempty"`
 	DateCreated *string `json:"date_created,omitempty"`
 	DateUpdated *string `json:"date_updated,omitempty"`
-	Token       *string `json:"token,omitempty"`
+
+	// Locale the device locale
+	Locale *string `json:"locale,omitempty"`
+	Token  *string `json:"token,omitempty"`
 }
 
 // Message defines model for Message.
 type Message struct {
-	Id                       *string                 `json:"_id,omitempty"`
-	AppId                    *string                 `json:"app_id,omitempty"`
-	Body                     *string                 `json:"body,omitempty"`
-	Data                     *[]string               `json:"data,omitempty"`
-	DateCreated              *string                 `json:"date_created,omitempty"`
-	DateUpdated              *string                 `json:"date_updated,omitempty"`
-	OrgId                    *string                 `json:"org_id,omitempty"`
-	Priority                 *string                 `json:"priority,omitempty"`
+	Id          *string   `json:"_id,omitempty"`
+	AppId       *string   `json:"app_id,omitempty"`
+	Body        *string   `json:"body,omitempty"`
+	CollapseKey *string   `json:"collapse_key,omitempty"`
+	Data        *[]string `json:"data,omitempty"`
+	DateCreated *string   `json:"date_created,omitempty"`
+	DateUpdated *string   `json:"date_updated,omitempty"`
+
+	// DeliveryMode alert, silent or inbox_only
+	DeliveryMode *string `json:"delivery_mode,omitempty"`
+	ExpiresAt    *string `json:"expires_at,omitempty"`
+
+	// Localizations locale -> the subject and the body in the locale language
+	Localizations *map[string]MessageLocalization `json:"localizations,omitempty"`
+	OrgId         *string                         `json:"org_id,omitempty"`
+	Priority      *string                         `json:"priority,omitempty"`
+
+	// PushOptions how the push notification is presented on the different platforms, all fields are optional
+	PushOptions              *PushOptions            `json:"push_options,omitempty"`
 	RecipientAccountCriteria *map[string]interface{} `json:"recipient_account_criteria,omitempty"`
 	Recipients               *Recipient              `json:"recipients,omitempty"`
 	RecipientsCriteriaList   *RecipientCriteria      `json:"recipients_criteria_list,omitempty"`
@@ -40,6 +83,26 @@ type Message struct {
 	Topic                    *string                 `json:"topic,omitempty"`
 }
 
+// MessageCancellation defines model for MessageCancellation.
+type MessageCancellation struct {
+	AppId       *string  `json:"app_id,omitempty"`
+	CancelledBy *Sender  `json:"cancelled_by,omitempty"`
+	DateCreated *string  `json:"date_created,omitempty"`
+	Id          *string  `json:"id,omitempty"`
+	Message     *Message `json:"message,omitempty"`
+	MessageId   *string  `json:"message_id,omitempty"`
+	OrgId       *string  `json:"org_id,omitempty"`
+
+	// QueueItemsCount the removed notifications
+	QueueItemsCount *int `json:"queue_items_count,omitempty"`
+}
+
+// MessageLocalization the message content in one language
+type MessageLocalization struct {
+	Body    *string `json:"body,omitempty"`
+	Subject *string `json:"subject,omitempty"`
+}
+
 // MessageRecipient defines model for MessageRecipient.
 type MessageRecipient struct {
 	AppId     *string `json:"app_id,omitempty"`
@@ -51,6 +114,190 @@ type MessageRecipient struct {
 	UserId    *string `json:"user_id,omitempty"`
 }
 
+// MessageSchedule defines model for MessageSchedule.
+type MessageSchedule struct {
+	AppId *string `json:"app_id,omitempty"`
+
+	// Cron minute hour day-of-month month day-of-week
+	Cron        *string `json:"cron,omitempty"`
+	DateCreated *string `json:"date_created,omitempty"`
+	DateUpdated *string `json:"date_updated,omitempty"`
+	Id          *string `json:"id,omitempty"`
+
+	// LastMessageId the message created on the last run
+	LastMessageId *string `json:"last_message_id,omitempty"`
+	LastRunAt     *string `json:"last_run_at,omitempty"`
+
+	// Message the message created on every schedule run
+	Message *MessageTemplate `json:"message,omitempty"`
+
+	// NextRunAt not set when the schedule is paused
+	NextRunAt *string `json:"next_run_at,omitempty"`
+	OrgId     *string `json:"org_id,omitempty"`
+	RunsCount *int    `json:"runs_count,omitempty"`
+
+	// Status active or paused
+	Status *string `json:"status,omitempty"`
+
+	// TimeZone IANA time zone in which the cron expression is evaluated
+	TimeZone *string `json:"time_zone,omitempty"`
+}
+
+// MessageTemplate the message created on every schedule run
+type MessageTemplate struct {
+	Body        *string            `json:"body,omitempty"`
+	CollapseKey *string            `json:"collapse_key,omitempty"`
+	Data        *map[string]string `json:"data,omitempty"`
+
+	// DeliveryMode alert, silent or inbox_only
+	DeliveryMode *string `json:"delivery_mode,omitempty"`
+
+	// Localizations locale -> the subject and the body in the locale language
+	Localizations *map[string]MessageLocalization `json:"localizations,omitempty"`
+	Priority      *int                            `json:"priority,omitempty"`
+
+	// PushOptions how the push notification is presented on the different platforms, all fields are optional
+	PushOptions              *PushOptions            `json:"push_options,omitempty"`
+	RecipientAccountCriteria *map[string]interface{} `json:"recipient_account_criteria,omitempty"`
+	Recipients               *[]MessageRecipient     `json:"recipients,omitempty"`
+	RecipientsCriteriaList   *[]RecipientCriteria    `json:"recipients_criteria_list,omitempty"`
+	Sender                   *Sender                 `json:"sender,omitempty"`
+	SilentInInbox            *bool                   `json:"silent_in_inbox,omitempty"`
+	Subject                  *string                 `json:"subject,omitempty"`
+	SupersedePrevious        *bool                   `json:"supersede_previous,omitempty"`
+	Topic                    *string                 `json:"topic,omitempty"`
+
+	// Ttl seconds after the run when the message expires
+	Ttl *int `json:"ttl,omitempty"`
+}
+
+// PushOptions how the push notification is presented on the different platforms, all fields are optional
+type PushOptions struct {
+	AndroidChannelId *string `json:"android_channel_id,omitempty"`
+
+	// ApnsCategory the iOS category with the notification actions
+	ApnsCategory *string `json:"apns_category,omitempty"`
+
+	// Badge the iOS app icon badge, 0 removes it
+	Badge *int `json:"badge,omitempty"`
+
+	// ImageUrl absolute http or https URL
+	ImageUrl *string `json:"image_url,omitempty"`
+
+	// Sound the sound file name in the app, "default" for the system sound
+	Sound *string `json:"sound,omitempty"`
+
+	// WebpushLink absolute https URL, opened when the web notification is clicked
+	WebpushLink *string `json:"webpush_link,omitempty"`
+}
+
+// Queue defines model for Queue.
+type Queue struct {
+	Id *string `json:"id,omitempty"`
+
+	// LeaseExpiresAt another instance can take over the queue after it
+	LeaseExpiresAt *string `json:"lease_expires_at,omitempty"`
+
+	// LeaseOwner the instance which processes the queue
+	LeaseOwner        *string `json:"lease_owner,omitempty"`
+	LeaseRenewedAt    *string `json:"lease_renewed_at,omitempty"`
+	Partition         *int    `json:"partition,omitempty"`
+	ProcessItemsCount *int    `json:"process_items_count,omitempty"`
+
+	// Status ready or processing
+	Status *string `json:"status,omitempty"`
+}
+
+// QueueDeadLetter defines model for QueueDeadLetter.
+type QueueDeadLetter struct {
+	AppId       *string            `json:"app_id,omitempty"`
+	Attempts    *int               `json:"attempts,omitempty"`
+	Body        *string            `json:"body,omitempty"`
+	CollapseKey *string            `json:"collapse_key,omitempty"`
+	Data        *map[string]string `json:"data,omitempty"`
+	DateCreated *string            `json:"date_created,omitempty"`
+	DigestOf    *[]string          `json:"digest_of,omitempty"`
+
+	// Error the last error
+	Error *string `json:"error,omitempty"`
+
+	// ErrorCode the code of the last error
+	ErrorCode *string `json:"error_code,omitempty"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+	Id        *string `json:"id,omitempty"`
+
+	// Localizations locale -> the subject and the body in the locale language
+	Localizations      *map[string]MessageLocalization `json:"localizations,omitempty"`
+	MessageId          *string                         `json:"message_id,omitempty"`
+	MessageRecipientId *string                         `json:"message_recipient_id,omitempty"`
+	OrgId              *string                         `json:"org_id,omitempty"`
+	Priority           *int                            `json:"priority,omitempty"`
+
+	// PushOptions how the push notification is presented on the different platforms, all fields are optional
+	PushOptions *PushOptions `json:"push_options,omitempty"`
+	QueueItemId *string      `json:"queue_item_id,omitempty"`
+
+	// Silent data-only notification
+	Silent  *bool   `json:"silent,omitempty"`
+	Subject *string `json:"subject,omitempty"`
+
+	// Tokens the tokens the item has failed for
+	Tokens *[]string `json:"tokens,omitempty"`
+	Topic  *string   `json:"topic,omitempty"`
+	UserId *string   `json:"user_id,omitempty"`
+}
+
+// QueueMessagePending defines model for QueueMessagePending.
+type QueueMessagePending struct {
+	Count     *int    `json:"count,omitempty"`
+	MessageId *string `json:"message_id,omitempty"`
+
+	// NextTime the time of the first upcoming item for the message
+	NextTime *string `json:"next_time,omitempty"`
+}
+
+// QueueOverview defines model for QueueOverview.
+type QueueOverview struct {
+	Pause   *QueuePause   `json:"pause,omitempty"`
+	Pending *QueuePending `json:"pending,omitempty"`
+}
+
+// QueuePause defines model for QueuePause.
+type QueuePause struct {
+	AppId       *string `json:"app_id,omitempty"`
+	DateCreated *string `json:"date_created,omitempty"`
+	Id          *string `json:"id,omitempty"`
+	OrgId       *string `json:"org_id,omitempty"`
+
+	// PausedBy the account which has paused the processing
+	PausedBy *string `json:"paused_by,omitempty"`
+}
+
+// QueuePending defines model for QueuePending.
+type QueuePending struct {
+	Count    *int                   `json:"count,omitempty"`
+	Messages *[]QueueMessagePending `json:"messages,omitempty"`
+
+	// NextTime the time of the first upcoming item for the org/app
+	NextTime *string `json:"next_time,omitempty"`
+}
+
+// QueueState defines model for QueueState.
+type QueueState struct {
+	// NextTime the time of the first upcoming item in the whole queue, the queue is processed then
+	NextTime   *string  `json:"next_time,omitempty"`
+	Partitions *[]Queue `json:"partitions,omitempty"`
+}
+
+// QuietHours daily window in the user time zone in which the user does not receive notifications
+type QuietHours struct {
+	// End HH:MM, it is on the next day if it is before the start
+	End *string `json:"end,omitempty"`
+
+	// Start HH:MM
+	Start *string `json:"start,omitempty"`
+}
+
 // Recipient defines model for Recipient.
 type Recipient struct {
 	Mute                 *bool   `json:"mute,omitempty"`
@@ -84,13 +331,20 @@ type Topic struct {
 
 // User defines model for User.
 type User struct {
-	Id                    *string        `json:"_id,omitempty"`
-	DateCreated           *string        `json:"date_created,omitempty"`
-	DateUpdated           *string        `json:"date_updated,omitempty"`
-	FirebaseTokens        *FirebaseToken `json:"firebase_tokens,omitempty"`
-	NotificationsDisabled *string        `json:"notifications_disabled,omitempty"`
-	Topics                *[]interface{} `json:"topics,omitempty"`
-	UserId                *string        `json:"user_id,omitempty"`
+	Id          *string `json:"_id,omitempty"`
+	DateCreated *string `json:"date_created,omitempty"`
+	DateUpdated *string `json:"date_updated,omitempty"`
+
+	// Digest the low priority notifications are bundled in one summary notification per interval. The summary data has type "digest", count and messages_ids
+	Digest                *DigestSettings `json:"digest,omitempty"`
+	FirebaseTokens        *FirebaseToken  `json:"firebase_tokens,omitempty"`
+	NotificationsDisabled *string         `json:"notifications_disabled,omitempty"`
+
+	// QuietHours daily window in the user time zone in which the user does not receive notifications
+	QuietHours *QuietHours    `json:"quiet_hours,omitempty"`
+	TimeZone   *string        `json:"time_zone,omitempty"`
+	Topics     *[]interface{} `json:"topics,omitempty"`
+	UserId     *string        `json:"user_id,omitempty"`
 }
 
 // AdminResGetMessagesStatsItem defines model for _admin_res_GetMessagesStatsItem.
@@ -112,6 +366,12 @@ type AdminResGetMessagesStatsSentByItem struct {
 	Name      *string `json:"name,omitempty"`
 }
 
+// AdminResReplayQueueDeadLetters defines model for _admin_res_ReplayQueueDeadLetters.
+type AdminResReplayQueueDeadLetters struct {
+	// Replayed how many dead letters have been put back in the queue, the replay can be repeated while it is equal to the limit
+	Replayed int `json:"replayed"`
+}
+
 // BbsReqAddRecipients defines model for _bbs_req_AddRecipients.
 type BbsReqAddRecipients = []struct {
 	Mute   bool   `json:"mute"`
@@ -151,7 +411,15 @@ type ClientReqToken struct {
 
 // ClientReqUser defines model for _client_req_user.
 type ClientReqUser struct {
-	NotificationsDisabled bool `json:"notifications_disabled"`
+	// Digest the low priority notifications are bundled in one summary notification per interval. The summary data has type "digest", count and messages_ids
+	Digest                *DigestSettings `json:"digest,omitempty"`
+	NotificationsDisabled bool            `json:"notifications_disabled"`
+
+	// QuietHours daily window in the user time zone in which the user does not receive notifications
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+
+	// TimeZone IANA time zone, the quiet hours are in it. Not changed if not given, removed if empty
+	TimeZone *string `json:"time_zone,omitempty"`
 }
 
 // SharedReqCreateMessage defines model for _shared_req_CreateMessage.
@@ -160,16 +428,39 @@ type SharedReqCreateMessage struct {
 	Body  string                 `json:"body"`
 	Data  map[string]interface{} `json:"data"`
 
+	// CollapseKey optional, max 64 characters - the still queued notifications with the same key are removed and the devices replace the earlier notification with the same key
+	CollapseKey *string `json:"collapse_key,omitempty"`
+
+	// DeliveryMode optional, alert, silent or inbox_only - alert is the default, silent wakes the app with a data-only push, inbox_only does not send a push
+	DeliveryMode *string `json:"delivery_mode,omitempty"`
+
+	// ExpiresAt optional, unix time in seconds - the message is not delivered and not shown in the inbox after it
+	ExpiresAt *int64 `json:"expires_at,omitempty"`
+
 	// Id optional
-	Id                       *string                                        `json:"id,omitempty"`
-	OrgId                    string                                         `json:"org_id"`
-	Priority                 int                                            `json:"priority"`
+	Id *string `json:"id,omitempty"`
+
+	// Localizations optional, locale (for example es or es-MX) -> the subject and the body in the locale language. The subject and the body are used when no locale matches
+	Localizations *map[string]MessageLocalization `json:"localizations,omitempty"`
+	OrgId         string                          `json:"org_id"`
+
+	// Priority 0 is the default, higher values are more urgent and are delivered first
+	Priority int `json:"priority"`
+
+	// PushOptions how the push notification is presented on the different platforms, all fields are optional
+	PushOptions              *PushOptions                                   `json:"push_options,omitempty"`
 	RecipientAccountCriteria map[string]interface{}                         `json:"recipient_account_criteria"`
 	Recipients               []SharedReqCreateMessageInputMessageRecipient  `json:"recipients"`
 	RecipientsCriteriaList   []SharedReqCreateMessageInputRecipientCriteria `json:"recipients_criteria_list"`
-	Subject                  string                                         `json:"subject"`
-	Time                     *int64                                         `json:"time,omitempty"`
-	Topic                    *string                                        `json:"topic,omitempty"`
+
+	// SilentInInbox optional, writes the silent message in the users inboxes
+	SilentInInbox *bool  `json:"silent_in_inbox,omitempty"`
+	Subject       string `json:"subject"`
+
+	// SupersedePrevious optional, marks the earlier inbox entries with the same collapse key as superseded
+	SupersedePrevious *bool   `json:"supersede_previous,omitempty"`
+	Time              *int64  `json:"time,omitempty"`
+	Topic             *string `json:"topic,omitempty"`
 }
 
 // SharedReqCreateMessageInputMessageRecipient defines model for _shared_req_CreateMessage_InputMessageRecipient.
@@ -184,9 +475,82 @@ type SharedReqCreateMessageInputRecipientCriteria struct {
 	AppVersion  *string `json:"app_version,omitempty"`
 }
 
+// SharedReqCreateMessageSchedule defines model for _shared_req_CreateMessageSchedule.
+type SharedReqCreateMessageSchedule struct {
+	// Cron standard 5 fields cron expression - minute hour day-of-month month day-of-week. For example `0 8 * * 1` is every Monday at 8:00
+	Cron    string                 `json:"cron"`
+	Message SharedReqCreateMessage `json:"message"`
+
+	// TimeZone IANA time zone in which the cron expression is evaluated, for example America/Chicago
+	TimeZone string `json:"time_zone"`
+
+	// Ttl optional, seconds after every run when the created message expires
+	Ttl *int `json:"ttl,omitempty"`
+}
+
 // SharedReqCreateMessages defines model for _shared_req_CreateMessages.
 type SharedReqCreateMessages = []SharedReqCreateMessage
 
+// SharedReqRescheduleMessage defines model for _shared_req_RescheduleMessage.
+type SharedReqRescheduleMessage struct {
+	// Time unix time in seconds - the new time of the message
+	Time int64 `json:"time"`
+}
+
+// SystemReqUpdateQueue defines model for _system_req_UpdateQueue.
+type SystemReqUpdateQueue struct {
+	// ProcessItemsCount how many items are loaded at once for every partition
+	ProcessItemsCount int `json:"process_items_count"`
+}
+
+// SystemReqUpdateQueuePartition defines model for _system_req_UpdateQueuePartition.
+type SystemReqUpdateQueuePartition struct {
+	// ProcessItemsCount how many items are loaded at once for the partition
+	ProcessItemsCount int `json:"process_items_count"`
+}
+
+// DeleteApiAdminDeadLettersParams defines parameters for DeleteApiAdminDeadLetters.
+type DeleteApiAdminDeadLettersParams struct {
+	// MessageId message_id - purge only the dead letters for a message
+	MessageId *string `json:"message_id,omitempty"`
+}
+
+// GetApiAdminDeadLettersParams defines parameters for GetApiAdminDeadLetters.
+type GetApiAdminDeadLettersParams struct {
+	// MessageId message_id - filter by message
+	MessageId *string `json:"message_id,omitempty"`
+
+	// Offset offset
+	Offset *string `json:"offset,omitempty"`
+
+	// Limit limit - Default: 100
+	Limit *string `json:"limit,omitempty"`
+}
+
+// PostApiAdminDeadLettersReplayParams defines parameters for PostApiAdminDeadLettersReplay.
+type PostApiAdminDeadLettersReplayParams struct {
+	// MessageId message_id - replay only the dead letters for a message
+	MessageId *string `json:"message_id,omitempty"`
+
+	// Limit limit - the max number of dead letters to replay. Default: 1000
+	Limit *string `json:"limit,omitempty"`
+}
+
+// GetApiAdminDeliveryAttemptsParams defines parameters for GetApiAdminDeliveryAttempts.
+type GetApiAdminDeliveryAttemptsParams struct {
+	// MessageId message_id - filter by message
+	MessageId *string `json:"message_id,omitempty"`
+
+	// UserId user_id - filter by user
+	UserId *string `json:"user_id,omitempty"`
+
+	// Offset offset
+	Offset *string `json:"offset,omitempty"`
+
+	// Limit limit - Default: 100
+	Limit *string `json:"limit,omitempty"`
+}
+
 // GetApiAdminMessagesParams defines parameters for GetApiAdminMessages.
 type GetApiAdminMessagesParams struct {
 	// Offset offset
@@ -205,6 +569,18 @@ type GetApiAdminMessagesParams struct {
 	EndDate string `json:"end_date"`
 }
 
+// GetApiAdminMessagesCancellationsParams defines parameters for GetApiAdminMessagesCancellations.
+type GetApiAdminMessagesCancellationsParams struct {
+	// MessageId message_id - filter by message
+	MessageId *string `json:"message_id,omitempty"`
+
+	// Offset offset
+	Offset *string `json:"offset,omitempty"`
+
+	// Limit limit - Default: 100
+	Limit *string `json:"limit,omitempty"`
+}
+
 // GetApiAdminMessagesStatsSourceSourceParams defines parameters for GetApiAdminMessagesStatsSourceSource.
 type GetApiAdminMessagesStatsSourceSourceParams struct {
 	// Offset offset
@@ -217,20 +593,50 @@ type GetApiAdminMessagesStatsSourceSourceParams struct {
 	Order *string `json:"order,omitempty"`
 }
 
+// GetApiAdminQueueParams defines parameters for GetApiAdminQueue.
+type GetApiAdminQueueParams struct {
+	// MessageId filter the pending items by message
+	MessageId *string `json:"message_id,omitempty"`
+}
+
+// GetApiAdminSchedulesParams defines parameters for GetApiAdminSchedules.
+type GetApiAdminSchedulesParams struct {
+	// Status status - active or paused
+	Status *string `json:"status,omitempty"`
+
+	// Offset offset
+	Offset *string `json:"offset,omitempty"`
+
+	// Limit limit - Default: 100
+	Limit *string `json:"limit,omitempty"`
+}
+
 // DeleteApiBbsMessagesParams defines parameters for DeleteApiBbsMessages.
 type DeleteApiBbsMessagesParams struct {
 	// Ids ids of the messages for deletion separated with comma
 	Ids string `json:"ids"`
 }
 
+// GetApiMessageIdParams defines parameters for GetApiMessageId.
+type GetApiMessageIdParams struct {
+	// AcceptLanguage the preferred languages, the messages subject and body are given in the best matching localization
+	AcceptLanguage *string `json:"Accept-Language,omitempty"`
+}
+
 // GetApiMessagesParams defines parameters for GetApiMessages.
 type GetApiMessagesParams struct {
+	// AcceptLanguage the preferred languages, the messages subject and body are given in the best matching localization
+	AcceptLanguage *string `json:"Accept-Language,omitempty"`
+
 	// Read read
 	Read *bool `json:"read,omitempty"`
 
 	// Mute mute
 	Mute *bool `json:"mute,omitempty"`
 
+	// IncludeExpired include_expired - gives the expired messages as well. Default - false
+	IncludeExpired *bool `json:"include_expired,omitempty"`
+
 	// Offset offset
 	Offset string `json:"offset"`
 
@@ -271,9 +677,15 @@ type PostApiAdminMessageJSONRequestBody = SharedReqCreateMessage
 // PutApiAdminMessageJSONRequestBody defines body for PutApiAdminMessage for application/json ContentType.
 type PutApiAdminMessageJSONRequestBody = SharedReqCreateMessage
 
+// PutApiAdminMessageIdRescheduleJSONRequestBody defines body for PutApiAdminMessageIdReschedule for application/json ContentType.
+type PutApiAdminMessageIdRescheduleJSONRequestBody = SharedReqRescheduleMessage
+
 // GetApiAdminMessagesJSONRequestBody defines body for GetApiAdminMessages for application/json ContentType.
 type GetApiAdminMessagesJSONRequestBody = ClientReqMessage
 
+// PostApiAdminSchedulesJSONRequestBody defines body for PostApiAdminSchedules for application/json ContentType.
+type PostApiAdminSchedulesJSONRequestBody = SharedReqCreateMessageSchedule
+
 // PutApiAdminTopicJSONRequestBody defines body for PutApiAdminTopic for application/json ContentType.
 type PutApiAdminTopicJSONRequestBody = Topic
 
@@ -292,6 +704,12 @@ type DeleteApiBbsMessagesMessageIdRecipientsJSONRequestBody = BbsReqRemoveRecipi
 // PostApiBbsMessagesMessageIdRecipientsJSONRequestBody defines body for PostApiBbsMessagesMessageIdRecipients for application/json ContentType.
 type PostApiBbsMessagesMessageIdRecipientsJSONRequestBody = BbsReqAddRecipients
 
+// PutApiBbsMessagesMessageIdRescheduleJSONRequestBody defines body for PutApiBbsMessagesMessageIdReschedule for application/json ContentType.
+type PutApiBbsMessagesMessageIdRescheduleJSONRequestBody = SharedReqRescheduleMessage
+
+// PostApiBbsSchedulesJSONRequestBody defines body for PostApiBbsSchedules for application/json ContentType.
+type PostApiBbsSchedulesJSONRequestBody = SharedReqCreateMessageSchedule
+
 // PostApiIntMailJSONRequestBody defines body for PostApiIntMail for application/json ContentType.
 type PostApiIntMailJSONRequestBody = ClientReqToken
 
@@ -310,6 +728,12 @@ type DeleteApiMessagesJSONRequestBody = ClientReqMessage
 // GetApiMessagesJSONRequestBody defines body for GetApiMessages for application/json ContentType.
 type GetApiMessagesJSONRequestBody = ClientReqMessage
 
+// PutApiSystemQueueJSONRequestBody defines body for PutApiSystemQueue for application/json ContentType.
+type PutApiSystemQueueJSONRequestBody = SystemReqUpdateQueue
+
+// PutApiSystemQueuePartitionsIdJSONRequestBody defines body for PutApiSystemQueuePartitionsId for application/json ContentType.
+type PutApiSystemQueuePartitionsIdJSONRequestBody = SystemReqUpdateQueuePartition
+
 // PostApiTokenJSONRequestBody defines body for PostApiToken for application/json ContentType.
 type PostApiTokenJSONRequestBody = ClientReqToken
 
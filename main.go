@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"notifications/core"
 	"notifications/core/model"
@@ -23,8 +24,12 @@ import (
 	"notifications/driven/mailer"
 	storage "notifications/driven/storage"
 	driver "notifications/driver/web"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth"
 	"github.com/rokwire/rokwire-building-block-sdk-go/services/core/auth/keys"
@@ -148,11 +153,51 @@ func main() {
 		NotificationsServiceURL: notificationsServiceURL,
 	}
 
+	// queue
+	queueWorkers := envLoader.GetAndLogEnvVar(envPrefix+"QUEUE_WORKERS", false, false)
+	queueBufferSize := envLoader.GetAndLogEnvVar(envPrefix+"QUEUE_BUFFER_SIZE", false, false)
+	queueBackpressureThreshold := envLoader.GetAndLogEnvVar(envPrefix+"QUEUE_BACKPRESSURE_THRESHOLD", false, false)
+	queuePartitions := envLoader.GetAndLogEnvVar(envPrefix+"QUEUE_PARTITIONS", false, false)
+	quietHoursBypassPriority := envLoader.GetAndLogEnvVar(envPrefix+"QUIET_HOURS_BYPASS_PRIORITY", false, false)
+	queueWorkersNum, _ := strconv.Atoi(queueWorkers)
+	queueBufferSizeNum, _ := strconv.Atoi(queueBufferSize)
+	queueBackpressureThresholdNum, _ := strconv.Atoi(queueBackpressureThreshold)
+	queuePartitionsNum, _ := strconv.Atoi(queuePartitions)
+	quietHoursBypassPriorityNum, _ := strconv.Atoi(quietHoursBypassPriority)
+	queueConfig := model.QueueConfig{WorkersCount: queueWorkersNum, BufferSize: queueBufferSizeNum,
+		BackpressureThreshold: queueBackpressureThresholdNum, PartitionsCount: queuePartitionsNum,
+		QuietHoursBypassPriority: quietHoursBypassPriorityNum}
+
 	// application
-	application := core.NewApplication(Version, Build, storageAdapter, firebaseAdapter, mailAdapter, logger, coreAdapter)
+	application := core.NewApplication(Version, Build, storageAdapter, firebaseAdapter, mailAdapter, logger, coreAdapter, queueConfig, core.NewSystemClock())
 	application.Start()
 
 	webAdapter := driver.NewWebAdapter(host, port, application, config, serviceRegManager, logger)
 
-	webAdapter.Start()
+	go webAdapter.Start()
+
+	// graceful shutdown
+	shutdownTimeout := envLoader.GetAndLogEnvVar(envPrefix+"SHUTDOWN_TIMEOUT", false, false)
+	shutdownTimeoutNum, err := strconv.Atoi(shutdownTimeout)
+	if err != nil || shutdownTimeoutNum <= 0 {
+		shutdownTimeoutNum = 25
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-signals
+	logger.Infof("%s received, shutting down", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(shutdownTimeoutNum)*time.Second)
+	defer cancel()
+
+	err = webAdapter.Stop(ctx)
+	if err != nil {
+		logger.Errorf("error on stopping the web adapter - %s", err)
+	}
+	err = application.Stop(ctx)
+	if err != nil {
+		logger.Errorf("error on stopping the application - %s", err)
+	}
+	logger.Info("shut down")
 }